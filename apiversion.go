@@ -0,0 +1,132 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type (
+	// semver is a parsed major.minor.patch version.
+	semver [3]int
+
+	// versionConstraint compares versions against version using op.
+	versionConstraint struct {
+		op      string
+		version semver
+	}
+)
+
+// APIVersionKey is the context key the APIVersionGate middleware stores the
+// requested version under, see `APIVersion()`.
+const APIVersionKey = "api_version"
+
+// APIVersionGate returns a middleware which rejects requests whose version in
+// header isn't in the supported range with a 400. The range is a space
+// separated list of constraints which must all match, e.g. ">=1.2 <2", using
+// the operators =, >, >=, < and <=. Versions may be prefixed with "v" and omit
+// the minor and patch number. The version of accepted requests is stored on
+// the context, see `APIVersion()`.
+//
+//	api := mux.Group("/api", route.APIVersionGate("X-API-Version", ">=1.2 <2"))
+func APIVersionGate(header, supported string) MiddlewareFunc {
+	constraints, err := parseVersionRange(supported)
+	if err != nil {
+		panic(fmt.Sprintf("route: %v", err))
+	}
+
+	return func(c Context, next HandlerFunc) error {
+		value := c.Request().Header.Get(header)
+		if value == "" {
+			return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("missing %s header", header))
+		}
+		v, err := parseSemver(value)
+		if err != nil {
+			return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+		}
+		for _, vc := range constraints {
+			if !vc.match(v) {
+				return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unsupported version %s, supported versions are %s", value, supported))
+			}
+		}
+		c.Set(APIVersionKey, v.String())
+		return next(c)
+	}
+}
+
+// APIVersion returns the version stored on c by the APIVersionGate middleware
+// in major.minor.patch form, e.g. "1.2.0".
+func APIVersion(c Context) string {
+	v, _ := c.Get(APIVersionKey).(string)
+	return v
+}
+
+// parseVersionRange parses space separated version constraints.
+func parseVersionRange(s string) ([]versionConstraint, error) {
+	var constraints []versionConstraint
+	for _, f := range strings.Fields(s) {
+		op := strings.TrimRight(f, "v0123456789.")
+		switch op {
+		case "":
+			op = "="
+		case "=", ">", ">=", "<", "<=":
+		default:
+			return nil, fmt.Errorf("invalid version constraint %q", f)
+		}
+		v, err := parseSemver(strings.TrimPrefix(f, op))
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, versionConstraint{op, v})
+	}
+	if len(constraints) == 0 {
+		return nil, fmt.Errorf("empty version range")
+	}
+	return constraints, nil
+}
+
+// parseSemver parses versions like "v1", "1.2" or "1.2.3".
+func parseSemver(s string) (v semver, err error) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "v"), ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("invalid version %q", s)
+	}
+	for i, p := range parts {
+		if v[i], err = strconv.Atoi(p); err != nil || v[i] < 0 {
+			return v, fmt.Errorf("invalid version %q", s)
+		}
+	}
+	return v, nil
+}
+
+func (v semver) compare(o semver) int {
+	for i := range v {
+		if v[i] != o[i] {
+			if v[i] < o[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func (v semver) String() string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
+
+func (vc versionConstraint) match(v semver) bool {
+	c := v.compare(vc.version)
+	switch vc.op {
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	}
+	return c == 0
+}
@@ -0,0 +1,54 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIVersionGate(t *testing.T) {
+	e := NewServeMux()
+	e.Use(APIVersionGate("X-API-Version", ">=1.2 <2"))
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, APIVersion(c))
+	})
+
+	tests := []struct {
+		version string
+		code    int
+		body    string
+	}{
+		{"1.2", http.StatusOK, "1.2.0"},
+		{"v1.9.3", http.StatusOK, "1.9.3"},
+		{"1.1.9", http.StatusBadRequest, ""},
+		{"2", http.StatusBadRequest, ""},
+		{"1.x", http.StatusBadRequest, ""},
+		{"", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.version != "" {
+			req.Header.Set("X-API-Version", tt.version)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, tt.code, rec.Code, tt.version)
+		if tt.code == http.StatusOK {
+			assert.Equal(t, tt.body, rec.Body.String())
+		}
+	}
+}
+
+func TestParseVersionRange(t *testing.T) {
+	constraints, err := parseVersionRange("1.2.3")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []versionConstraint{{"=", semver{1, 2, 3}}}, constraints)
+	}
+	for _, r := range []string{"", "~1.2", ">=1.2.3.4", "=>1"} {
+		_, err := parseVersionRange(r)
+		assert.Error(t, err, r)
+	}
+	assert.Panics(t, func() { APIVersionGate("X-API-Version", "^1") })
+}
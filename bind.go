@@ -1,9 +1,12 @@
 package route
 
 import (
+	stdcontext "context"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"reflect"
 	"strconv"
@@ -16,32 +19,255 @@ type (
 		Bind(i interface{}, c Context) error
 	}
 
+	// AllBinder is implemented by Binders which can bind all sources of a
+	// request at once, see `Context#BindAll()`.
+	AllBinder interface {
+		BindAll(i interface{}, c Context) error
+	}
+
 	// DefaultBinder is the default implementation of the Binder interface.
-	DefaultBinder struct{}
+	DefaultBinder struct {
+		// NumberFormat sets the locale specific notation used to parse numeric
+		// form, query, param and header values, e.g. "1.234,56". Go notation
+		// is used when nil.
+		NumberFormat *NumberFormat
+
+		// DisallowUnknownFields rejects JSON bodies with fields which don't
+		// match a field of the bound value with `ErrBadRequest`. The bodies
+		// are decoded with encoding/json instead of the registered
+		// JSONSerializer in that case.
+		DisallowUnknownFields bool
+
+		// MaxJSONBodySize limits the size of JSON bodies in bytes. Larger
+		// bodies are rejected with `ErrStatusRequestEntityTooLarge`. Default
+		// unlimited.
+		MaxJSONBodySize int64
+
+		// SplitCommaValues splits form and query values bound to slice fields
+		// at commas, so that `?ids=1,2,3` binds the same as
+		// `?ids=1&ids=2&ids=3`. Values are split before NumberFormat is
+		// applied, so it can't use "," as a separator.
+		SplitCommaValues bool
+
+		// BindAllOrder lists the sources bound by `BindAll()` from lowest to
+		// highest precedence. Default body, query, header, param.
+		BindAllOrder []BindSource
+	}
+
+	// BindSource is a source of the values bound by `DefaultBinder#BindAll()`.
+	BindSource string
+
+	// JSONBinderOption configures the binder returned by `NewJSONBinder()`.
+	JSONBinderOption func(*DefaultBinder)
+
+	// NumberFormat describes the separators a locale uses when writing numbers.
+	NumberFormat struct {
+		DecimalSeparator string
+		GroupSeparator   string
+	}
 
 	// BindUnmarshaler is the interface used to wrap the UnmarshalParam method.
 	BindUnmarshaler interface {
 		// UnmarshalParam decodes and assigns a value from an form or query param.
 		UnmarshalParam(param string) error
 	}
+
+	// ctxReader reads from r until ctx is done. Reads are passed to a
+	// goroutine, so that a read blocked on a slow client can be abandoned.
+	ctxReader struct {
+		ctx  stdcontext.Context
+		r    io.ReadCloser
+		buf  []byte
+		reqs chan int
+		res  chan readResult
+		err  error // Set once a read was abandoned
+	}
+
+	readResult struct {
+		n   int
+		err error
+	}
+
+	// maxBytesReader reads up to n bytes from r and fails with
+	// errBodyTooLarge after that.
+	maxBytesReader struct {
+		r io.ReadCloser
+		n int64
+	}
+)
+
+// Bind sources
+const (
+	BindSourceBody   BindSource = "body"
+	BindSourceQuery  BindSource = "query"
+	BindSourceHeader BindSource = "header"
+	BindSourceParam  BindSource = "param"
 )
 
-// Bind implements the `Binder#Bind` function.
-func (b *DefaultBinder) Bind(i interface{}, c Context) (err error) {
+// defaultBindAllOrder is the precedence of the sources bound by BindAll if
+// BindAllOrder isn't set.
+var defaultBindAllOrder = []BindSource{BindSourceBody, BindSourceQuery, BindSourceHeader, BindSourceParam}
+
+// errBodyTooLarge is returned by maxBytesReader once the limit is exceeded.
+var errBodyTooLarge = errors.New("request body too large")
+
+// maxBindIndex limits the slice indices accepted in form and query keys, e.g.
+// "items[999]", to bound the memory allocated for binding.
+const maxBindIndex = 1000
+
+// NewJSONBinder returns a DefaultBinder hardened for decoding JSON bodies with
+// opts, e.g.
+//
+//	route.NewJSONBinder(route.WithDisallowUnknownFields(), route.WithMaxJSONBodySize(1<<20))
+func NewJSONBinder(opts ...JSONBinderOption) *DefaultBinder {
+	b := new(DefaultBinder)
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// WithDisallowUnknownFields rejects JSON bodies with unknown fields, see
+// `DefaultBinder#DisallowUnknownFields`.
+func WithDisallowUnknownFields() JSONBinderOption {
+	return func(b *DefaultBinder) {
+		b.DisallowUnknownFields = true
+	}
+}
+
+// WithMaxJSONBodySize limits the size of JSON bodies to n bytes, see
+// `DefaultBinder#MaxJSONBodySize`.
+func WithMaxJSONBodySize(n int64) JSONBinderOption {
+	return func(b *DefaultBinder) {
+		b.MaxJSONBodySize = n
+	}
+}
+
+// Bind implements the `Binder#Bind` function. Path params and headers are bound
+// to the fields tagged with `param` and `header` before the request body, or the
+// query params of GET and DELETE requests, are bound.
+//
+// Reading the body is aborted with `ErrRequestTimeout` once `Context#StdContext()`
+// is done, e.g. because the deadline set by the Timeout middleware passed while
+// a slow client was still sending the body.
+func (b *DefaultBinder) Bind(i interface{}, c Context) error {
+	return bindWithContext(c, func() error {
+		return b.bind(i, c)
+	})
+}
+
+// BindAll binds the sources of BindAllOrder to the struct i one after another,
+// so that values of later sources override those of earlier ones. With the
+// default order, path params take precedence over headers, headers over query
+// params and query params over the request body. The body is only bound if the
+// request has one. The first error is returned.
+func (b *DefaultBinder) BindAll(i interface{}, c Context) error {
+	order := b.BindAllOrder
+	if order == nil {
+		order = defaultBindAllOrder
+	}
+	return bindWithContext(c, func() error {
+		for _, source := range order {
+			var err error
+			switch source {
+			case BindSourceBody:
+				if c.Request().ContentLength != 0 {
+					err = b.bindBody(i, c)
+				}
+			case BindSourceQuery:
+				err = b.BindQueryParams(c, i)
+			case BindSourceHeader:
+				err = b.BindHeaders(c, i)
+			case BindSourceParam:
+				err = b.BindPathParams(c, i)
+			default:
+				err = fmt.Errorf("route: unknown bind source %q", source)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// bindWithContext calls bind, aborting reads of the request body once the
+// deadline of `Context#StdContext()` passed. Contexts without a deadline are
+// only cancelled when the client went away, in which case the server ends the
+// reads itself.
+//
+// A read which was abandoned is finished in the background, after which the
+// body is closed. The request body fails with the context error from then on,
+// so that it isn't read concurrently.
+func bindWithContext(c Context, bind func() error) (err error) {
+	ctx := c.StdContext()
+	if _, ok := ctx.Deadline(); ok && c.Request().Body != nil {
+		req := c.Request()
+		body := req.Body
+		cr := newCtxReader(ctx, body)
+		req.Body = cr
+		defer func() {
+			close(cr.reqs)
+			if cr.err == nil {
+				req.Body = body
+			}
+		}()
+	}
+	if err = bind(); err != nil && ctx.Err() != nil {
+		return ErrRequestTimeout
+	}
+	return
+}
+
+func (b *DefaultBinder) bind(i interface{}, c Context) (err error) {
+	if reflect.Indirect(reflect.ValueOf(i)).Kind() == reflect.Struct {
+		if err = b.BindPathParams(c, i); err != nil {
+			return
+		}
+		if err = b.BindHeaders(c, i); err != nil {
+			return
+		}
+	}
 	req := c.Request()
 	if req.ContentLength == 0 {
 		if req.Method == http.MethodGet || req.Method == http.MethodDelete {
-			if err = b.bindData(i, c.QueryParams(), "query"); err != nil {
-				return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
-			}
-			return
+			return b.BindQueryParams(c, i)
 		}
 		return NewHTTPError(http.StatusBadRequest, "Request body can't be empty")
 	}
+	return b.bindBody(i, c)
+}
+
+// bindBody binds the request body based on its Content-Type.
+func (b *DefaultBinder) bindBody(i interface{}, c Context) (err error) {
+	req := c.Request()
 	ctype := req.Header.Get(HeaderContentType)
+	if ctype == "" {
+		ctype = c.Mux().DefaultContentType
+	}
 	switch {
 	case strings.HasPrefix(ctype, MIMEApplicationJSON):
-		if err = json.NewDecoder(req.Body).Decode(i); err != nil {
+		if b.MaxJSONBodySize > 0 {
+			if req.ContentLength > b.MaxJSONBodySize {
+				return ErrStatusRequestEntityTooLarge
+			}
+			body := req.Body
+			req.Body = &maxBytesReader{r: body, n: b.MaxJSONBodySize}
+			defer func() {
+				req.Body = body
+			}()
+		}
+		if b.DisallowUnknownFields {
+			dec := json.NewDecoder(req.Body)
+			dec.DisallowUnknownFields()
+			err = dec.Decode(i)
+		} else {
+			err = c.Mux().jsonSerializer().Deserialize(c, i)
+		}
+		if err != nil {
+			if err == errBodyTooLarge {
+				return ErrStatusRequestEntityTooLarge
+			}
 			if ute, ok := err.(*json.UnmarshalTypeError); ok {
 				return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unmarshal type error: expected=%v, got=%v, field=%v, offset=%v", ute.Type, ute.Value, ute.Field, ute.Offset)).SetInternal(err)
 			} else if se, ok := err.(*json.SyntaxError); ok {
@@ -49,12 +275,21 @@ func (b *DefaultBinder) Bind(i interface{}, c Context) (err error) {
 			}
 			return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 		}
+	case strings.HasPrefix(ctype, MIMEApplicationXML), strings.HasPrefix(ctype, MIMETextXML):
+		if err = xml.NewDecoder(req.Body).Decode(i); err != nil {
+			if ute, ok := err.(*xml.UnsupportedTypeError); ok {
+				return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unsupported type error: type=%v, error=%v", ute.Type, ute.Error())).SetInternal(err)
+			} else if se, ok := err.(*xml.SyntaxError); ok {
+				return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Syntax error: line=%v, error=%v", se.Line, se.Error())).SetInternal(err)
+			}
+			return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+		}
 	case strings.HasPrefix(ctype, MIMEApplicationForm), strings.HasPrefix(ctype, MIMEMultipartForm):
 		params, err := c.FormParams()
 		if err != nil {
 			return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 		}
-		if err = b.bindData(i, params, "form"); err != nil {
+		if err = b.bindData(i, normalizeFormKeys(params), "form"); err != nil {
 			return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 		}
 	default:
@@ -63,13 +298,127 @@ func (b *DefaultBinder) Bind(i interface{}, c Context) (err error) {
 	return
 }
 
+func (r *maxBytesReader) Read(p []byte) (int, error) {
+	if r.n < 0 {
+		return 0, errBodyTooLarge
+	}
+	if int64(len(p)) > r.n+1 {
+		p = p[:r.n+1]
+	}
+	n, err := r.r.Read(p)
+	r.n -= int64(n)
+	if r.n < 0 {
+		return 0, errBodyTooLarge
+	}
+	return n, err
+}
+
+func newCtxReader(ctx stdcontext.Context, r io.ReadCloser) *ctxReader {
+	cr := &ctxReader{ctx: ctx, r: r, reqs: make(chan int), res: make(chan readResult, 1)}
+	go cr.serve()
+	return cr
+}
+
+// serve reads into buf for every request until reqs is closed. It closes r if
+// a read was abandoned.
+func (r *ctxReader) serve() {
+	for n := range r.reqs {
+		m, err := r.r.Read(r.buf[:n])
+		r.res <- readResult{m, err}
+	}
+	select {
+	case <-r.res: // The result of the abandoned read
+		r.r.Close()
+	default:
+	}
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if err := r.ctx.Err(); err != nil {
+		r.err = err
+		return 0, err
+	}
+	if len(r.buf) < len(p) {
+		r.buf = make([]byte, len(p))
+	}
+	// serve reads into its own buffer, which is abandoned when ctx is done
+	// first.
+	r.reqs <- len(p)
+	select {
+	case res := <-r.res:
+		return copy(p, r.buf[:res.n]), res.err
+	case <-r.ctx.Done():
+		r.err = r.ctx.Err()
+		return 0, r.err
+	}
+}
+
+func (r *ctxReader) Close() error {
+	if r.err != nil {
+		return nil
+	}
+	return r.r.Close()
+}
+
+func (r *maxBytesReader) Close() error {
+	return r.r.Close()
+}
+
+// BindPathParams binds the path params of the request to the fields of i
+// tagged with `param`, e.g. `param:"id"`.
+func (b *DefaultBinder) BindPathParams(c Context, i interface{}) error {
+	names := c.ParamNames()
+	values := c.ParamValues()
+	params := make(map[string][]string, len(names))
+	for n, name := range names {
+		if n < len(values) {
+			params[name] = []string{values[n]}
+		}
+	}
+	if err := b.bindData(i, params, "param"); err != nil {
+		return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	return nil
+}
+
+// BindQueryParams binds the query params of the request to the fields of i
+// tagged with `query`, e.g. `query:"page"`.
+func (b *DefaultBinder) BindQueryParams(c Context, i interface{}) error {
+	if err := b.bindData(i, normalizeFormKeys(c.QueryParams()), "query"); err != nil {
+		return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	return nil
+}
+
+// BindHeaders binds the request headers to the fields of i tagged with
+// `header`, e.g. `header:"X-Request-ID"`. Slice fields receive all values of
+// multi-valued headers.
+func (b *DefaultBinder) BindHeaders(c Context, i interface{}) error {
+	if err := b.bindData(i, c.Request().Header, "header"); err != nil {
+		return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	return nil
+}
+
 func (b *DefaultBinder) bindData(ptr interface{}, data map[string][]string, tag string) error {
+	return b.bindFields(ptr, data, tag, nil)
+}
+
+// bindFields binds data to the fields of the struct ptr points to. outer holds
+// the types of the structs whose untagged fields ptr was reached through, so
+// that recursive types like `type Node struct{ Parent *Node }` aren't bound
+// endlessly.
+func (b *DefaultBinder) bindFields(ptr interface{}, data map[string][]string, tag string, outer []reflect.Type) error {
 	typ := reflect.TypeOf(ptr).Elem()
 	val := reflect.ValueOf(ptr).Elem()
 
 	if typ.Kind() != reflect.Struct {
 		return errors.New("binding element must be a struct")
 	}
+	outer = append(outer, typ)
 
 	for i := 0; i < typ.NumField(); i++ {
 		typeField := typ.Field(i)
@@ -78,19 +427,43 @@ func (b *DefaultBinder) bindData(ptr interface{}, data map[string][]string, tag
 			continue
 		}
 		structFieldKind := structField.Kind()
-		inputFieldName := typeField.Tag.Get(tag)
+		inputFieldName, quoted := parseBindTag(typeField.Tag.Get(tag))
 
 		if inputFieldName == "" {
 			inputFieldName = typeField.Name
-			// If tag is nil, we inspect if the field is a struct.
+			// If tag is nil, we inspect if the field is a struct, or a pointer
+			// to one which is allocated if data contains values for it.
+			if isStructPtr(structField) {
+				if !containsType(outer, structField.Type().Elem()) {
+					if err := b.bindStructPtr(structField, data, tag, outer); err != nil {
+						return err
+					}
+				}
+				if nestable(tag) {
+					if err := b.bindNested(structField, inputFieldName, data, tag); err != nil {
+						return err
+					}
+				}
+				continue
+			}
 			if _, ok := bindUnmarshaler(structField); !ok && structFieldKind == reflect.Struct {
-				if err := b.bindData(structField.Addr().Interface(), data, tag); err != nil {
+				if err := b.bindFields(structField.Addr().Interface(), data, tag, outer); err != nil {
 					return err
 				}
+				if nestable(tag) {
+					if err := b.bindNested(structField, inputFieldName, data, tag); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			// Path params and headers are only bound to tagged fields.
+			if tag == "param" || tag == "header" {
 				continue
 			}
 		}
 
+		fieldName := inputFieldName
 		inputValue, exists := data[inputFieldName]
 		if !exists {
 			// Go json.Unmarshal supports case insensitive binding.  However the
@@ -108,13 +481,41 @@ func (b *DefaultBinder) bindData(ptr interface{}, data map[string][]string, tag
 		}
 
 		if !exists {
+			if nestable(tag) {
+				if err := b.bindNested(structField, fieldName, data, tag); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if b.SplitCommaValues && nestable(tag) && structFieldKind == reflect.Slice {
+			if _, ok := bindUnmarshaler(structField); !ok {
+				inputValue = splitCommaValues(inputValue)
+			}
+		}
+
+		if quoted {
+			inputValue = unquoteValues(inputValue)
+		}
+
+		if enum, ok := typeField.Tag.Lookup("enum"); ok {
+			if err := checkEnum(enum, inputValue); err != nil {
+				return &fieldError{fieldName, err}
+			}
+		}
+
+		if b.NumberFormat != nil && isNumberKind(typeField.Type) {
+			inputValue = b.NumberFormat.normalize(inputValue)
+		}
+		if len(inputValue) == 0 {
 			continue
 		}
 
 		// Call this first, in case we're dealing with an alias to an array type
 		if ok, err := unmarshalField(typeField.Type.Kind(), inputValue[0], structField); ok {
 			if err != nil {
-				return err
+				return &fieldError{fieldName, err}
 			}
 			continue
 		}
@@ -125,18 +526,370 @@ func (b *DefaultBinder) bindData(ptr interface{}, data map[string][]string, tag
 			slice := reflect.MakeSlice(structField.Type(), numElems, numElems)
 			for j := 0; j < numElems; j++ {
 				if err := setWithProperType(sliceOf, inputValue[j], slice.Index(j)); err != nil {
-					return err
+					return &fieldError{fieldName + "[" + strconv.Itoa(j) + "]", err}
 				}
 			}
 			val.Field(i).Set(slice)
 		} else if err := setWithProperType(typeField.Type.Kind(), inputValue[0], structField); err != nil {
-			return err
+			return &fieldError{fieldName, err}
+		}
+	}
+	return nil
+}
+
+// isStructPtr reports whether field is a pointer to a struct which isn't bound
+// using BindUnmarshaler.
+func isStructPtr(field reflect.Value) bool {
+	if field.Kind() != reflect.Ptr || field.Type().Elem().Kind() != reflect.Struct {
+		return false
+	}
+	_, ok := bindUnmarshaler(reflect.New(field.Type().Elem()).Elem())
+	return !ok
+}
+
+// bindStructPtr binds data to the struct the pointer field points to. A nil
+// field is only set if any of its fields were bound.
+func (b *DefaultBinder) bindStructPtr(field reflect.Value, data map[string][]string, tag string, outer []reflect.Type) error {
+	if !field.IsNil() {
+		return b.bindFields(field.Interface(), data, tag, outer)
+	}
+	v := reflect.New(field.Type().Elem())
+	if err := b.bindFields(v.Interface(), data, tag, outer); err != nil {
+		return err
+	}
+	if !v.Elem().IsZero() {
+		field.Set(v)
+	}
+	return nil
+}
+
+func containsType(types []reflect.Type, t reflect.Type) bool {
+	for _, u := range types {
+		if u == t {
+			return true
+		}
+	}
+	return false
+}
+
+// checkEnum returns an error listing the allowed values if one of values isn't
+// in the comma separated enum.
+func checkEnum(enum string, values []string) error {
+	allowed := strings.Split(enum, ",")
+	for _, v := range values {
+		found := false
+		for _, a := range allowed {
+			if v == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("invalid value %q, allowed values are %s", v, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// sanitize runs the string fields of the struct v points to which are tagged
+// with `sanitize:"html"` through s, including those of nested structs.
+func sanitize(v reflect.Value, s Sanitizer) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			sanitize(v.Elem(), s)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			sanitize(v.Index(i), s)
+		}
+	case reflect.Struct:
+		typ := v.Type()
+		for i := 0; i < typ.NumField(); i++ {
+			field := v.Field(i)
+			if !field.CanSet() {
+				continue
+			}
+			if typ.Field(i).Tag.Get("sanitize") == "html" {
+				sanitizeStrings(field, s)
+				continue
+			}
+			sanitize(field, s)
+		}
+	}
+}
+
+// sanitizeStrings runs the string field, or the elements of the string slice
+// field, through s.
+func sanitizeStrings(field reflect.Value, s Sanitizer) {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s.Sanitize(field.String()))
+	case reflect.Ptr:
+		if !field.IsNil() {
+			sanitizeStrings(field.Elem(), s)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < field.Len(); i++ {
+			sanitizeStrings(field.Index(i), s)
+		}
+	}
+}
+
+// nestable reports whether values bound by tag may address nested struct fields
+// and slice elements, e.g. "address.city" or "items[0].name".
+func nestable(tag string) bool {
+	return tag == "form" || tag == "query"
+}
+
+// bindNested binds the values of data addressing the fields or elements of the
+// struct or slice field by name, e.g. "name.city" or "name[0]".
+func (b *DefaultBinder) bindNested(field reflect.Value, name string, data map[string][]string, tag string) error {
+	switch field.Kind() {
+	case reflect.Ptr:
+		if field.Type().Elem().Kind() != reflect.Struct {
+			return nil
+		}
+		sub := nestedData(data, name+".")
+		if len(sub) == 0 {
+			return nil
+		}
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return prefixFieldError(name, b.bindData(field.Interface(), sub, tag))
+	case reflect.Struct:
+		sub := nestedData(data, name+".")
+		if len(sub) == 0 {
+			return nil
+		}
+		return prefixFieldError(name, b.bindData(field.Addr().Interface(), sub, tag))
+	case reflect.Slice:
+		return b.bindIndexed(field, name, data, tag)
+	}
+	return nil
+}
+
+// bindIndexed binds the values of data keyed by name followed by an index, e.g.
+// "items[0]" or "items[0].name", to the elements of the slice field.
+func (b *DefaultBinder) bindIndexed(field reflect.Value, name string, data map[string][]string, tag string) error {
+	elems := map[int]map[string][]string{}
+	max := -1
+	prefix := strings.ToLower(name) + "["
+	for k, v := range data {
+		if len(k) <= len(prefix) || strings.ToLower(k[:len(prefix)]) != prefix {
+			continue
+		}
+		end := strings.IndexByte(k[len(prefix):], ']')
+		if end < 0 {
+			continue
+		}
+		idx, err := strconv.Atoi(k[len(prefix) : len(prefix)+end])
+		if err != nil || idx < 0 {
+			continue
+		}
+		rest := k[len(prefix)+end+1:]
+		if rest != "" && rest[0] != '.' {
+			continue
+		}
+		if idx >= maxBindIndex {
+			return &fieldError{k, fmt.Errorf("index exceeds %d", maxBindIndex-1)}
+		}
+		if elems[idx] == nil {
+			elems[idx] = map[string][]string{}
+		}
+		elems[idx][strings.TrimPrefix(rest, ".")] = v
+		if idx > max {
+			max = idx
+		}
+	}
+	if max < 0 {
+		return nil
+	}
 
+	slice := reflect.MakeSlice(field.Type(), max+1, max+1)
+	for idx, sub := range elems {
+		elem := slice.Index(idx)
+		elemName := name + "[" + strconv.Itoa(idx) + "]"
+		if elem.Kind() == reflect.Ptr {
+			elem.Set(reflect.New(elem.Type().Elem()))
+			elem = elem.Elem()
+		}
+		if v, ok := sub[""]; ok {
+			if err := setWithProperType(elem.Kind(), v[0], elem); err != nil {
+				return &fieldError{elemName, err}
+			}
+			continue
+		}
+		if elem.Kind() != reflect.Struct {
+			continue
+		}
+		if err := b.bindData(elem.Addr().Interface(), sub, tag); err != nil {
+			return prefixFieldError(elemName, err)
 		}
 	}
+	field.Set(slice)
 	return nil
 }
 
+// nestedData returns the values of data whose keys start with prefix, keyed by
+// the rest of the key. Keys are compared case-insensitively.
+func nestedData(data map[string][]string, prefix string) map[string][]string {
+	prefix = strings.ToLower(prefix)
+	sub := map[string][]string{}
+	for k, v := range data {
+		if len(k) > len(prefix) && strings.ToLower(k[:len(prefix)]) == prefix {
+			sub[k[len(prefix):]] = v
+		}
+	}
+	return sub
+}
+
+// normalizeFormKeys adds the keys of data using bracket notation for fields,
+// e.g. "address[city]", in dot notation, e.g. "address.city".
+func normalizeFormKeys(data map[string][]string) map[string][]string {
+	var normalized map[string][]string
+	for k, v := range data {
+		n := normalizeFormKey(k)
+		if n == k {
+			continue
+		}
+		if normalized == nil {
+			normalized = make(map[string][]string, len(data))
+			for k, v := range data {
+				normalized[k] = v
+			}
+		}
+		if _, ok := normalized[n]; !ok {
+			normalized[n] = v
+		}
+	}
+	if normalized == nil {
+		return data
+	}
+	return normalized
+}
+
+func normalizeFormKey(k string) string {
+	i := strings.IndexByte(k, '[')
+	if i <= 0 {
+		return k
+	}
+	var sb strings.Builder
+	sb.WriteString(k[:i])
+	for i < len(k) {
+		if k[i] != '[' {
+			sb.WriteString(k[i:])
+			break
+		}
+		end := strings.IndexByte(k[i:], ']')
+		if end < 0 {
+			return k
+		}
+		inner := k[i+1 : i+end]
+		if _, err := strconv.Atoi(inner); err == nil || inner == "" {
+			sb.WriteString(k[i : i+end+1])
+		} else {
+			sb.WriteString("." + inner)
+		}
+		i += end + 1
+	}
+	return sb.String()
+}
+
+// fieldError is an error binding a value to the named field.
+type fieldError struct {
+	field string
+	err   error
+}
+
+func (e *fieldError) Error() string {
+	return e.field + ": " + e.err.Error()
+}
+
+func (e *fieldError) Unwrap() error {
+	return e.err
+}
+
+// prefixFieldError prefixes the field name of a fieldError with the name of the
+// field containing it.
+func prefixFieldError(name string, err error) error {
+	if fe, ok := err.(*fieldError); ok {
+		return &fieldError{name + "." + fe.field, fe.err}
+	}
+	return err
+}
+
+// normalize rewrites numbers written in the f notation into Go notation.
+func (f *NumberFormat) normalize(values []string) []string {
+	normalized := make([]string, len(values))
+	for i, v := range values {
+		if f.GroupSeparator != "" {
+			v = strings.Replace(v, f.GroupSeparator, "", -1)
+		}
+		if f.DecimalSeparator != "" {
+			v = strings.Replace(v, f.DecimalSeparator, ".", -1)
+		}
+		normalized[i] = v
+	}
+	return normalized
+}
+
+// parseBindTag splits a struct tag value into the field name and whether the
+// `string` option is set, following the encoding/json tag format.
+func parseBindTag(tag string) (name string, quoted bool) {
+	i := strings.IndexByte(tag, ',')
+	if i < 0 {
+		return tag, false
+	}
+	for _, opt := range strings.Split(tag[i+1:], ",") {
+		if opt == "string" {
+			quoted = true
+		}
+	}
+	return tag[:i], quoted
+}
+
+// splitCommaValues splits each of values at commas, trimming the spaces around
+// the elements.
+func splitCommaValues(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, e := range strings.Split(v, ",") {
+			out = append(out, strings.TrimSpace(e))
+		}
+	}
+	return out
+}
+
+// unquoteValues strips the surrounding double quotes from values written as
+// Go string literals, leaving other values untouched.
+func unquoteValues(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		if u, err := strconv.Unquote(v); err == nil && strings.HasPrefix(v, `"`) {
+			v = u
+		}
+		out[i] = v
+	}
+	return out
+}
+
+// isNumberKind reports whether t, or the type it points to or holds in a slice,
+// is numeric.
+func isNumberKind(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
 func setWithProperType(valueKind reflect.Kind, val string, structField reflect.Value) error {
 	// But also call it here, in case we're dealing with an array of BindUnmarshalers
 	if ok, err := unmarshalField(valueKind, val, structField); ok {
@@ -2,12 +2,19 @@ package route
 
 import (
 	"bytes"
+	stdcontext "context"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"io"
+	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -123,6 +130,47 @@ func TestBindJSON(t *testing.T) {
 	testBindError(assert, strings.NewReader(userJSONInvalidType), MIMEApplicationJSON, &json.UnmarshalTypeError{})
 }
 
+func TestBindXML(t *testing.T) {
+	assert := assert.New(t)
+	testBindOkay(assert, strings.NewReader(userXML), MIMEApplicationXML)
+	testBindError(assert, strings.NewReader(invalidContent), MIMEApplicationXML, errors.New(""))
+	testBindOkay(assert, strings.NewReader(userXML), MIMETextXML)
+	testBindError(assert, strings.NewReader("<user><id>x</id></user>"), MIMETextXML, &strconv.NumError{})
+}
+
+func TestBindXMLNamespace(t *testing.T) {
+	body := `<?xml version="1.0"?>
+<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope" xmlns:o="urn:example:orders">
+	<env:Body>
+		<o:order o:id="42" currency="EUR">
+			<o:item>book</o:item>
+			<item>ignored</item>
+		</o:order>
+	</env:Body>
+</env:Envelope>`
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(HeaderContentType, MIMEApplicationXMLCharsetUTF8)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	order := struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2003/05/soap-envelope Envelope"`
+		Body    struct {
+			Order struct {
+				ID       int    `xml:"urn:example:orders id,attr"`
+				Currency string `xml:"currency,attr"`
+				Item     string `xml:"urn:example:orders item"`
+			} `xml:"urn:example:orders order"`
+		} `xml:"http://www.w3.org/2003/05/soap-envelope Body"`
+	}{}
+	if assert.NoError(t, c.Bind(&order)) {
+		assert.Equal(t, 42, order.Body.Order.ID)
+		assert.Equal(t, "EUR", order.Body.Order.Currency)
+		assert.Equal(t, "book", order.Body.Order.Item)
+	}
+}
+
 func TestBindForm(t *testing.T) {
 	assert := assert.New(t)
 
@@ -238,6 +286,543 @@ func TestBindbindData(t *testing.T) {
 	assertBindTestStruct(assert, ts)
 }
 
+func TestBindDefaultContentType(t *testing.T) {
+	e := NewServeMux(WithDefaultContentType(MIMEApplicationJSON))
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(userJSON))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	u := new(user)
+	err := c.Bind(u)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, u.ID)
+		assert.Equal(t, "Jon Snow", u.Name)
+	}
+
+	// Without a default the content type is unsupported
+	e = NewServeMux()
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(userJSON))
+	c = e.NewContext(req, rec)
+	assert.Equal(t, ErrUnsupportedMediaType, c.Bind(u))
+}
+
+func TestBindJSONBinder(t *testing.T) {
+	e := NewServeMux(WithBinder(NewJSONBinder(WithDisallowUnknownFields(), WithMaxJSONBodySize(64))))
+	bind := func(body string, length int64) error {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+		req.ContentLength = length
+		c := e.NewContext(req, httptest.NewRecorder())
+		return c.Bind(new(user))
+	}
+
+	assert.NoError(t, bind(userJSON, int64(len(userJSON))))
+
+	err := bind(`{"id":1,"nmae":"Jon Snow"}`, -1)
+	if he, ok := err.(*HTTPError); assert.True(t, ok) {
+		assert.Equal(t, http.StatusBadRequest, he.Code)
+		assert.Equal(t, `json: unknown field "nmae"`, he.Message)
+	}
+
+	large := `{"id":1,"name":"` + strings.Repeat("x", 64) + `"}`
+	assert.Equal(t, ErrStatusRequestEntityTooLarge, bind(large, int64(len(large))))
+	// Bodies of unknown length are cut off at the limit
+	assert.Equal(t, ErrStatusRequestEntityTooLarge, bind(large, -1))
+
+	// Defaults are lenient
+	e = NewServeMux()
+	assert.NoError(t, bind(`{"id":1,"nmae":"Jon Snow"}`, -1))
+	assert.NoError(t, bind(large, -1))
+}
+
+func TestBindContextDeadline(t *testing.T) {
+	e := NewServeMux()
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go pw.Write([]byte(`{"id":1,`)) // Incomplete body
+
+	ctx, cancel := stdcontext.WithTimeout(stdcontext.Background(), 20*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodPost, "/", pr).WithContext(ctx)
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	c := e.NewContext(req, httptest.NewRecorder())
+	assert.Equal(t, ErrRequestTimeout, c.Bind(new(user)))
+
+	// The abandoned read is finished in the background, the body isn't
+	// read again
+	_, err := req.Body.Read(make([]byte, 1))
+	assert.Equal(t, stdcontext.DeadlineExceeded, err)
+	pw.Write([]byte(`"name":"Jon Snow"}`))
+	_, err = pw.Write([]byte("more"))
+	assert.Equal(t, io.ErrClosedPipe, err)
+
+	// Complete bodies are bound as usual
+	ctx, cancel = stdcontext.WithTimeout(stdcontext.Background(), time.Minute)
+	defer cancel()
+	body := ioutil.NopCloser(strings.NewReader(userJSON))
+	req = httptest.NewRequest(http.MethodPost, "/", body).WithContext(ctx)
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	c = e.NewContext(req, httptest.NewRecorder())
+	u := new(user)
+	if assert.NoError(t, c.Bind(u)) {
+		assert.Equal(t, "Jon Snow", u.Name)
+		assert.Equal(t, body, req.Body)
+	}
+
+	// Bodies of requests without deadline aren't wrapped
+	ctx, cancel = stdcontext.WithCancel(stdcontext.Background())
+	defer cancel()
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(userJSON)).WithContext(ctx)
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	s := new(bodyTypeJSONSerializer)
+	c = NewServeMux(WithJSONSerializer(s)).NewContext(req, httptest.NewRecorder())
+	if assert.NoError(t, c.Bind(new(user))) {
+		_, wrapped := s.body.(*ctxReader)
+		assert.False(t, wrapped)
+	}
+}
+
+// bodyTypeJSONSerializer records the request body it deserializes from.
+type bodyTypeJSONSerializer struct {
+	defaultJSONSerializer
+	body io.Reader
+}
+
+func (s *bodyTypeJSONSerializer) Deserialize(c Context, i interface{}) error {
+	s.body = c.Request().Body
+	return s.defaultJSONSerializer.Deserialize(c, i)
+}
+
+func TestBindNumberFormat(t *testing.T) {
+	e := NewServeMux(WithBinder(&DefaultBinder{
+		NumberFormat: &NumberFormat{DecimalSeparator: ",", GroupSeparator: "."},
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("price=1.234,56&count=1.000"))
+	req.Header.Set(HeaderContentType, MIMEApplicationForm)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	result := struct {
+		Price float64 `form:"price"`
+		Count int     `form:"count"`
+	}{}
+	err := c.Bind(&result)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1234.56, result.Price)
+		assert.Equal(t, 1000, result.Count)
+	}
+
+	// Go notation is used by default
+	req = httptest.NewRequest(http.MethodGet, "/?price=1234.56", nil)
+	c = NewServeMux().NewContext(req, rec)
+	err = c.Bind(&result)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1234.56, result.Price)
+	}
+
+	// Keys without values are skipped
+	b := &DefaultBinder{NumberFormat: &NumberFormat{DecimalSeparator: ","}}
+	result.Price = 0
+	assert.NoError(t, b.bindData(&result, map[string][]string{"price": {}}, "form"))
+	assert.Zero(t, result.Price)
+}
+
+func TestBindStringOption(t *testing.T) {
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":"123"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	result := struct {
+		ID int `json:"id,string" query:"id,string"`
+	}{}
+	if assert.NoError(t, c.Bind(&result)) {
+		assert.Equal(t, 123, result.ID)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/?id=%22456%22", nil)
+	c = e.NewContext(req, rec)
+	if assert.NoError(t, c.Bind(&result)) {
+		assert.Equal(t, 456, result.ID)
+	}
+
+	// Unquoted values are accepted as well
+	req = httptest.NewRequest(http.MethodGet, "/?id=789", nil)
+	c = e.NewContext(req, rec)
+	if assert.NoError(t, c.Bind(&result)) {
+		assert.Equal(t, 789, result.ID)
+	}
+}
+
+func TestBindPathParams(t *testing.T) {
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodPut, "/users/1/posts/2", strings.NewReader(`{"title":"Hello"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id", "pid")
+	c.SetParamValues("1", "2")
+
+	result := struct {
+		UserID int    `param:"id"`
+		PostID int    `param:"pid"`
+		Title  string `json:"title"`
+		ID     string
+	}{}
+	if assert.NoError(t, c.Bind(&result)) {
+		assert.Equal(t, 1, result.UserID)
+		assert.Equal(t, 2, result.PostID)
+		assert.Equal(t, "Hello", result.Title)
+		assert.Equal(t, "", result.ID)
+	}
+
+	// Path params only
+	result.Title = ""
+	result.UserID = 0
+	if assert.NoError(t, c.BindPathParams(&result)) {
+		assert.Equal(t, 1, result.UserID)
+		assert.Equal(t, "", result.Title)
+	}
+
+	c.SetParamValues("x", "2")
+	err := c.BindPathParams(&result)
+	if assert.IsType(t, new(HTTPError), err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*HTTPError).Code)
+	}
+
+	// Binding into non-struct types skips path params
+	req = httptest.NewRequest(http.MethodPut, "/users/1/posts/2", strings.NewReader(`{"title":"Hello"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	c.SetRequest(req)
+	m := map[string]interface{}{}
+	if assert.NoError(t, c.Bind(&m)) {
+		assert.Equal(t, "Hello", m["title"])
+	}
+}
+
+func TestBindHeaders(t *testing.T) {
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodGet, "/?id=1", nil)
+	req.Header.Set(HeaderXRequestID, "abc")
+	req.Header.Set("X-Api-Version", "2")
+	req.Header.Add("Accept-Language", "en")
+	req.Header.Add("Accept-Language", "de")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	result := struct {
+		ID         int      `query:"id"`
+		RequestID  string   `header:"X-Request-ID"`
+		APIVersion int      `header:"x-api-version"`
+		Languages  []string `header:"Accept-Language"`
+		Accept     string
+	}{}
+	if assert.NoError(t, c.Bind(&result)) {
+		assert.Equal(t, 1, result.ID)
+		assert.Equal(t, "abc", result.RequestID)
+		assert.Equal(t, 2, result.APIVersion)
+		assert.Equal(t, []string{"en", "de"}, result.Languages)
+	}
+
+	// Headers only
+	result.ID = 0
+	result.RequestID = ""
+	if assert.NoError(t, c.BindHeaders(&result)) {
+		assert.Equal(t, 0, result.ID)
+		assert.Equal(t, "abc", result.RequestID)
+	}
+
+	req.Header.Set("X-Api-Version", "two")
+	err := c.BindHeaders(&result)
+	if assert.IsType(t, new(HTTPError), err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*HTTPError).Code)
+	}
+}
+
+func TestBindNilStructPtr(t *testing.T) {
+	type contact struct {
+		Email string `form:"email" query:"email"`
+		Phone string `form:"phone" query:"phone"`
+	}
+	type IDs struct {
+		ID int `param:"id"`
+	}
+	type user struct {
+		*IDs
+		Name    string `form:"name" query:"name"`
+		Contact *contact
+		Backup  *contact
+		Work    *contact `form:"work" query:"work"`
+	}
+
+	form := url.Values{
+		"name":        {"Jon"},
+		"email":       {"jon@example.com"},
+		"work[phone]": {"123"},
+	}
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	req.Header.Set(HeaderContentType, MIMEApplicationForm)
+	c := e.NewContext(req, httptest.NewRecorder())
+	c.SetParamNames("id")
+	c.SetParamValues("7")
+	u := new(user)
+	if assert.NoError(t, c.Bind(u)) {
+		if assert.NotNil(t, u.IDs) {
+			assert.Equal(t, 7, u.ID)
+		}
+		if assert.NotNil(t, u.Contact) {
+			assert.Equal(t, "jon@example.com", u.Contact.Email)
+		}
+		if assert.NotNil(t, u.Work) {
+			assert.Equal(t, "123", u.Work.Phone)
+		}
+	}
+
+	// Not allocated without data
+	req = httptest.NewRequest(http.MethodGet, "/?name=Jon&backup.phone=456", nil)
+	c = e.NewContext(req, httptest.NewRecorder())
+	u = new(user)
+	if assert.NoError(t, c.Bind(u)) {
+		assert.Nil(t, u.IDs)
+		assert.Nil(t, u.Contact)
+		assert.Nil(t, u.Work)
+		if assert.NotNil(t, u.Backup) {
+			assert.Equal(t, "456", u.Backup.Phone)
+		}
+	}
+}
+
+func TestBindRecursiveStructPtr(t *testing.T) {
+	type node struct {
+		Name   string `query:"name" json:"name"`
+		Parent *node  `json:"parent"`
+	}
+	type tree struct {
+		Root node
+	}
+
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodGet, "/?name=a&parent.name=b", nil)
+	c := e.NewContext(req, httptest.NewRecorder())
+	n := new(node)
+	if assert.NoError(t, c.Bind(n)) {
+		assert.Equal(t, "a", n.Name)
+		if assert.NotNil(t, n.Parent) {
+			assert.Equal(t, "b", n.Parent.Name)
+			assert.Nil(t, n.Parent.Parent)
+		}
+	}
+
+	// Cycle through a flattened struct and a non-nil pointer
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"a"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	c = e.NewContext(req, httptest.NewRecorder())
+	tr := new(tree)
+	tr.Root.Parent = &tr.Root
+	if assert.NoError(t, c.Bind(&tr.Root)) {
+		assert.Equal(t, "a", tr.Root.Name)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/?name=c", nil)
+	c = e.NewContext(req, httptest.NewRecorder())
+	if assert.NoError(t, c.Bind(tr)) {
+		assert.Equal(t, "c", tr.Root.Name)
+	}
+}
+
+func TestBindNestedForm(t *testing.T) {
+	type item struct {
+		Name string `form:"name"`
+		Qty  int    `form:"qty"`
+	}
+	type address struct {
+		City string `form:"city"`
+		Zip  string `form:"zip"`
+	}
+	type order struct {
+		Items    []item   `form:"items"`
+		Refs     []*item  `form:"refs"`
+		Tags     []string `form:"tags"`
+		Address  address  `form:"address"`
+		Shipping *address `form:"shipping"`
+		Billing  address
+	}
+
+	form := url.Values{
+		"items[0].name":      {"foo"},
+		"items[0].qty":       {"2"},
+		"items[1][name]":     {"bar"},
+		"refs[0].name":       {"baz"},
+		"tags[0]":            {"a"},
+		"tags[1]":            {"b"},
+		"address.city":       {"Berlin"},
+		"address[zip]":       {"10115"},
+		"shipping.city":      {"Paris"},
+		"billing.city":       {"Rome"},
+		"unrelated[0].field": {"x"},
+	}
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	req.Header.Set(HeaderContentType, MIMEApplicationForm)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	o := new(order)
+	if assert.NoError(t, c.Bind(o)) {
+		assert.Equal(t, []item{{Name: "foo", Qty: 2}, {Name: "bar"}}, o.Items)
+		if assert.Len(t, o.Refs, 1) {
+			assert.Equal(t, "baz", o.Refs[0].Name)
+		}
+		assert.Equal(t, []string{"a", "b"}, o.Tags)
+		assert.Equal(t, address{City: "Berlin", Zip: "10115"}, o.Address)
+		if assert.NotNil(t, o.Shipping) {
+			assert.Equal(t, "Paris", o.Shipping.City)
+		}
+		assert.Equal(t, "Rome", o.Billing.City)
+	}
+
+	// Query params
+	req = httptest.NewRequest(http.MethodGet, "/?items[0].name=foo&address.city=Berlin", nil)
+	c = e.NewContext(req, rec)
+	o = new(order)
+	if assert.NoError(t, c.Bind(o)) {
+		assert.Equal(t, []item{{Name: "foo"}}, o.Items)
+		assert.Equal(t, "Berlin", o.Address.City)
+	}
+
+	// Type mismatch
+	req = httptest.NewRequest(http.MethodGet, "/?items[1].qty=x", nil)
+	c = e.NewContext(req, rec)
+	err := c.Bind(new(order))
+	if assert.IsType(t, new(HTTPError), err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*HTTPError).Code)
+		assert.Equal(t, `Items[1].Qty: strconv.ParseInt: parsing "x": invalid syntax`, err.(*HTTPError).Message)
+	}
+
+	// Index out of range
+	req = httptest.NewRequest(http.MethodGet, "/?items[100000].qty=1", nil)
+	c = e.NewContext(req, rec)
+	err = c.Bind(new(order))
+	if assert.IsType(t, new(HTTPError), err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*HTTPError).Code)
+	}
+}
+
+type userValidator struct{}
+
+func (userValidator) Validate(i interface{}) error {
+	if u, ok := i.(*user); ok && u.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+func TestBindValidate(t *testing.T) {
+	e := NewServeMux(WithValidator(userValidator{}))
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"id":1,"name":"Jon Snow"}`, 0},
+		{`{"id":1,"name":""}`, http.StatusUnprocessableEntity},
+		{`{"id":1,"name":`, http.StatusBadRequest},
+		{`{"id":"1","name":""}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+		req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		err := c.Bind(new(user))
+		if tt.code == 0 {
+			assert.NoError(t, err)
+			continue
+		}
+		if assert.IsType(t, new(HTTPError), err, tt.body) {
+			assert.Equal(t, tt.code, err.(*HTTPError).Code, tt.body)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":1,"name":""}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	c := e.NewContext(req, httptest.NewRecorder())
+	err := c.Bind(new(user))
+	assert.Equal(t, "name is required", err.(*HTTPError).Message)
+
+	// Without validator
+	c = NewServeMux().NewContext(req, httptest.NewRecorder())
+	assert.Equal(t, ErrValidatorNotRegistered, c.Validate(new(user)))
+}
+
+// scriptSanitizer strips <script> elements for testing, real sanitizers do a
+// lot more.
+type scriptSanitizer struct{}
+
+func (scriptSanitizer) Sanitize(s string) string {
+	return regexp.MustCompile(`(?is)<script.*?</script>`).ReplaceAllString(s, "")
+}
+
+func TestBindSanitize(t *testing.T) {
+	type comment struct {
+		Body    string   `json:"body" sanitize:"html"`
+		Tags    []string `json:"tags" sanitize:"html"`
+		Raw     string   `json:"raw"`
+		Replies []struct {
+			Body *string `json:"body" sanitize:"html"`
+		} `json:"replies"`
+	}
+	body := `{"body":"Hi<script>alert(1)</script>!","tags":["<script>x</script>go"],"raw":"<script></script>","replies":[{"body":"<SCRIPT>alert(2)</SCRIPT>ok"}]}`
+
+	e := NewServeMux(WithSanitizer(scriptSanitizer{}))
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	c := e.NewContext(req, httptest.NewRecorder())
+	v := comment{}
+	if assert.NoError(t, c.Bind(&v)) {
+		assert.Equal(t, "Hi!", v.Body)
+		assert.Equal(t, []string{"go"}, v.Tags)
+		assert.Equal(t, "<script></script>", v.Raw)
+		if assert.Len(t, v.Replies, 1) {
+			assert.Equal(t, "ok", *v.Replies[0].Body)
+		}
+	}
+
+	// Without a Sanitizer
+	e = NewServeMux()
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	c = e.NewContext(req, httptest.NewRecorder())
+	v = comment{}
+	if assert.NoError(t, c.Bind(&v)) {
+		assert.Equal(t, "Hi<script>alert(1)</script>!", v.Body)
+	}
+}
+
+type status string
+
+func TestBindEnum(t *testing.T) {
+	e := NewServeMux()
+	result := struct {
+		Status status   `query:"status" enum:"active,inactive,pending"`
+		Tags   []string `query:"tag" enum:"a,b"`
+	}{}
+
+	req := httptest.NewRequest(http.MethodGet, "/?status=pending&tag=a&tag=b", nil)
+	c := e.NewContext(req, httptest.NewRecorder())
+	if assert.NoError(t, c.Bind(&result)) {
+		assert.Equal(t, status("pending"), result.Status)
+		assert.Equal(t, []string{"a", "b"}, result.Tags)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/?status=deleted", nil)
+	c = e.NewContext(req, httptest.NewRecorder())
+	err := c.Bind(&result)
+	if assert.IsType(t, new(HTTPError), err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*HTTPError).Code)
+		assert.Equal(t, `status: invalid value "deleted", allowed values are active, inactive, pending`, err.(*HTTPError).Message)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/?tag=a&tag=c", nil)
+	c = e.NewContext(req, httptest.NewRecorder())
+	assert.Error(t, c.Bind(&result))
+}
+
 func TestBindUnmarshalTypeError(t *testing.T) {
 	body := bytes.NewBufferString(`{ "id": "text" }`)
 	e := NewServeMux()
@@ -383,3 +968,134 @@ func testBindError(assert *assert.Assertions, r io.Reader, ctype string, expecte
 		}
 	}
 }
+
+func TestBindSliceValues(t *testing.T) {
+	result := struct {
+		IDs  []int    `query:"ids"`
+		Tags []string `query:"tag"`
+	}{}
+
+	// Repeated keys
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodGet, "/?ids=1&ids=2&ids=3&tag=a,b", nil)
+	c := e.NewContext(req, httptest.NewRecorder())
+	if assert.NoError(t, c.Bind(&result)) {
+		assert.Equal(t, []int{1, 2, 3}, result.IDs)
+		assert.Equal(t, []string{"a,b"}, result.Tags)
+	}
+
+	// Comma separated values
+	e = NewServeMux(WithBinder(&DefaultBinder{SplitCommaValues: true}))
+	req = httptest.NewRequest(http.MethodGet, "/?ids=1,2&ids=3&tag=a,+b", nil)
+	c = e.NewContext(req, httptest.NewRecorder())
+	if assert.NoError(t, c.Bind(&result)) {
+		assert.Equal(t, []int{1, 2, 3}, result.IDs)
+		assert.Equal(t, []string{"a", "b"}, result.Tags)
+	}
+
+	// Form values
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("ids=4,5"))
+	req.Header.Set(HeaderContentType, MIMEApplicationForm)
+	c = e.NewContext(req, httptest.NewRecorder())
+	form := struct {
+		IDs []int `form:"ids"`
+	}{}
+	if assert.NoError(t, c.Bind(&form)) {
+		assert.Equal(t, []int{4, 5}, form.IDs)
+	}
+
+	// Invalid elements are reported with their index
+	req = httptest.NewRequest(http.MethodGet, "/?ids=1,x,3", nil)
+	c = e.NewContext(req, httptest.NewRecorder())
+	err := c.Bind(&result)
+	if assert.IsType(t, new(HTTPError), err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*HTTPError).Code)
+		assert.Contains(t, err.(*HTTPError).Message, "ids[1]: ")
+	}
+}
+
+func TestBindAll(t *testing.T) {
+	type dto struct {
+		ID        int    `param:"id" query:"id" json:"id"`
+		Name      string `query:"name" json:"name"`
+		Page      int    `query:"page"`
+		RequestID string `header:"X-Request-ID"`
+	}
+
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodPut, "/?id=2&page=3", strings.NewReader(`{"id":1,"name":"Jon Snow"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	req.Header.Set("X-Request-ID", "abc")
+	c := e.NewContext(req, httptest.NewRecorder())
+	c.SetParamNames("id")
+	c.SetParamValues("3")
+	r := new(dto)
+	if assert.NoError(t, c.BindAll(r)) {
+		assert.Equal(t, dto{ID: 3, Name: "Jon Snow", Page: 3, RequestID: "abc"}, *r)
+	}
+
+	// Without body and path params
+	req = httptest.NewRequest(http.MethodGet, "/?id=2&name=Arya", nil)
+	c = e.NewContext(req, httptest.NewRecorder())
+	r = new(dto)
+	if assert.NoError(t, c.BindAll(r)) {
+		assert.Equal(t, dto{ID: 2, Name: "Arya"}, *r)
+	}
+
+	// Custom order
+	e = NewServeMux(WithBinder(&DefaultBinder{BindAllOrder: []BindSource{BindSourceQuery, BindSourceBody}}))
+	req = httptest.NewRequest(http.MethodPost, "/?id=2", strings.NewReader(`{"id":1}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	c = e.NewContext(req, httptest.NewRecorder())
+	r = new(dto)
+	if assert.NoError(t, c.BindAll(r)) {
+		assert.Equal(t, 1, r.ID)
+	}
+
+	// The first error is returned
+	req = httptest.NewRequest(http.MethodGet, "/?page=x", nil)
+	c = e.NewContext(req, httptest.NewRecorder())
+	c.SetParamNames("id")
+	c.SetParamValues("x")
+	err := c.BindAll(new(dto))
+	if assert.IsType(t, new(HTTPError), err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*HTTPError).Code)
+		assert.Contains(t, err.(*HTTPError).Message, "page")
+	}
+}
+
+type allBinder struct {
+	*DefaultBinder
+	calls int
+}
+
+func (b *allBinder) BindAll(i interface{}, c Context) error {
+	b.calls++
+	return b.DefaultBinder.BindAll(i, c)
+}
+
+func TestBindAllBinder(t *testing.T) {
+	type dto struct {
+		Name string `query:"name"`
+	}
+	b := &allBinder{DefaultBinder: new(DefaultBinder)}
+	e := NewServeMux(WithBinder(b))
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/?name=Jon", nil), httptest.NewRecorder())
+	r := new(dto)
+	if assert.NoError(t, c.BindAll(r)) {
+		assert.Equal(t, "Jon", r.Name)
+		assert.Equal(t, 1, b.calls)
+	}
+
+	// Binders without BindAll fall back to a DefaultBinder
+	e = NewServeMux(WithBinder(bodyOnlyBinder{}))
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/?name=Arya", nil), httptest.NewRecorder())
+	r = new(dto)
+	if assert.NoError(t, c.BindAll(r)) {
+		assert.Equal(t, "Arya", r.Name)
+	}
+}
+
+type bodyOnlyBinder struct{}
+
+func (bodyOnlyBinder) Bind(i interface{}, c Context) error { return nil }
@@ -0,0 +1,75 @@
+package route
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ContentTypeConfig defines the config for EnforceContentType middleware.
+type ContentTypeConfig struct {
+	// Skipper defines a function to skip middleware. Default
+	// `SafeMethodSkipper`.
+	Skipper Skipper
+
+	// Types is the list of allowed media types, e.g. `MIMEApplicationJSON`.
+	// Parameters such as the charset are ignored. Required.
+	Types []string
+}
+
+// EnforceContentType returns a middleware which rejects requests with a body
+// whose Content-Type isn't one of types with `ErrUnsupportedMediaType`, e.g. to
+// prevent JSON handlers from accepting form data. Requests with safe methods,
+// which usually have no body, are skipped. Only the media type is compared,
+// so "application/json; charset=utf-8" matches `MIMEApplicationJSON`.
+func EnforceContentType(types ...string) MiddlewareFunc {
+	return EnforceContentTypeWithConfig(ContentTypeConfig{Types: types})
+}
+
+// EnforceContentTypeWithConfig returns an EnforceContentType middleware with
+// config.
+// See: `EnforceContentType()`.
+func EnforceContentTypeWithConfig(config ContentTypeConfig) MiddlewareFunc {
+	if len(config.Types) == 0 {
+		panic("route: content type middleware requires types")
+	}
+	if config.Skipper == nil {
+		config.Skipper = SafeMethodSkipper
+	}
+	allowed := make(map[string]bool, len(config.Types))
+	for _, t := range config.Types {
+		mt, _, err := mime.ParseMediaType(t)
+		if err != nil {
+			panic("route: invalid content type " + t)
+		}
+		allowed[mt] = true
+	}
+
+	return func(c Context, next HandlerFunc) error {
+		if config.Skipper(c) {
+			return next(c)
+		}
+
+		req := c.Request()
+		ctype := req.Header.Get(HeaderContentType)
+		if ctype == "" && req.ContentLength == 0 {
+			return next(c)
+		}
+		mt, _, err := mime.ParseMediaType(ctype)
+		if err != nil || !allowed[mt] {
+			return ErrUnsupportedMediaType
+		}
+		return next(c)
+	}
+}
+
+// SafeMethodSkipper skips middleware for requests with a safe method, i.e.
+// GET, HEAD, OPTIONS and TRACE, which don't modify resources and usually have
+// no body.
+func SafeMethodSkipper(c Context) bool {
+	switch strings.ToUpper(c.Request().Method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnforceContentType(t *testing.T) {
+	e := NewServeMux()
+	e.Use(EnforceContentType(MIMEApplicationJSON))
+	h := func(c Context) error { return c.String(http.StatusOK, "OK") }
+	e.GET("/", h)
+	e.POST("/", h)
+	e.DELETE("/", h)
+
+	tests := []struct {
+		method string
+		ctype  string
+		body   string
+		code   int
+	}{
+		{http.MethodPost, MIMEApplicationJSON, userJSON, http.StatusOK},
+		{http.MethodPost, MIMEApplicationJSONCharsetUTF8, userJSON, http.StatusOK},
+		{http.MethodPost, "Application/JSON", userJSON, http.StatusOK},
+		{http.MethodPost, MIMEApplicationForm, "id=1", http.StatusUnsupportedMediaType},
+		{http.MethodPost, "", userJSON, http.StatusUnsupportedMediaType},
+		{http.MethodPost, "application/json;;", userJSON, http.StatusUnsupportedMediaType},
+		// Safe methods and requests without a body are skipped
+		{http.MethodGet, MIMEApplicationForm, "id=1", http.StatusOK},
+		{http.MethodDelete, "", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body))
+		if tt.ctype != "" {
+			req.Header.Set(HeaderContentType, tt.ctype)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, tt.code, rec.Code, tt.method+" "+tt.ctype)
+	}
+
+	assert.Panics(t, func() {
+		EnforceContentType()
+	})
+}
@@ -1,16 +1,30 @@
 package route
 
 import (
+	"bufio"
 	"bytes"
+	stdcontext "context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/textproto"
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
+	"syscall"
 )
 
 type (
@@ -23,18 +37,67 @@ type (
 		// SetRequest sets `*http.Request`.
 		SetRequest(r *http.Request)
 
+		// BodyReader returns a reader over the request body, which always
+		// starts at the beginning. The body is read into memory on the first
+		// call, so that multiple consumers, e.g. a signature check and `Bind`,
+		// can read it. The request body is replaced to read from the cached
+		// bytes as well. Bodies larger than `Mux#BodyLimit` fail with
+		// `ErrStatusRequestEntityTooLarge`.
+		BodyReader() io.Reader
+
+		// BodyBytes returns the request body, which is read into memory and
+		// cached like by `BodyReader`, e.g. to verify an HMAC signature before
+		// the body is bound. The returned slice must not be modified.
+		BodyBytes() ([]byte, error)
+
+		// StdContext returns the `context.Context` of the request.
+		StdContext() stdcontext.Context
+
+		// SetStdContext replaces the request with a copy using ctx, e.g. to
+		// propagate a deadline to downstream handlers.
+		SetStdContext(ctx stdcontext.Context)
+
 		// Response returns `*Response`.
 		Response() *Response
 
+		// RealIP returns the client's network address. The `X-Forwarded-For`
+		// and `X-Real-IP` request headers are only used if the request comes
+		// from a proxy trusted with `Mux#SetTrustedProxies()`, as they are set
+		// by the client otherwise. The remote address is used by default.
+		RealIP() string
+
+		// AddLink adds a link to a related resource to the `Link` header of the
+		// response, e.g. AddLink("next", "/users?page=2"). Links are written
+		// in a single header, in the order they were added.
+		// See: https://tools.ietf.org/html/rfc8288
+		AddLink(rel, href string)
+
+		// SetTrailer sets the trailer header key, which is sent after the response
+		// body. It may be called before or after the body is written.
+		SetTrailer(key, value string)
+
+		// Hijack lets the handler take over the connection, e.g. to upgrade it
+		// to a WebSocket. See `Response#Hijack()`.
+		Hijack() (net.Conn, *bufio.ReadWriter, error)
+
 		// Path returns the registered path for the handler.
 		Path() string
 
 		// SetPath sets the registered path for the handler.
 		SetPath(p string)
 
-		// Param returns path parameter by name.
+		// RouteInfo returns the matched route, or nil if no route matched.
+		RouteInfo() *Route
+
+		// Param returns path parameter by name. Values are taken from the
+		// request path as matched by the router, see `ParamWildcard()`.
 		Param(name string) string
 
+		// ParamWildcard returns the unescaped path matched by the `*`
+		// catch-all of the route, including its slashes, e.g. "a/b/c" for
+		// "/files/a/b/c" matched by "/files/*".
+		ParamWildcard() string
+
 		// ParamNames returns path parameter names.
 		ParamNames() []string
 
@@ -47,6 +110,9 @@ type (
 		// SetParamValues sets path parameter values.
 		SetParamValues(values ...string)
 
+		// Params returns all path parameters as a map of names to values.
+		Params() map[string]string
+
 		// QueryParam returns the query param for the provided name.
 		QueryParam(name string) string
 
@@ -65,15 +131,62 @@ type (
 		// FormFile returns the multipart form file for the provided name.
 		FormFile(name string) (*multipart.FileHeader, error)
 
-		// MultipartForm returns the multipart form.
+		// MultipartForm returns the multipart form, parsed with the memory
+		// limit `Mux#MultipartMemoryLimit`.
 		MultipartForm() (*multipart.Form, error)
 
+		// MultipartFormWithLimit returns the multipart form. Up to maxMemory
+		// bytes of the files are stored in memory, the remainder is spilled to
+		// temporary files on disk, which are removed after the request was
+		// served. The form is parsed only once, so the limit has no effect if
+		// it was parsed before, e.g. by `FormValue` or `Bind`.
+		MultipartFormWithLimit(maxMemory int64) (*multipart.Form, error)
+
+		// MultipartReader returns a reader over the parts of a multipart
+		// request body, e.g. to stream large uploads to storage part by part
+		// instead of parsing the whole form. It consumes the body, so it fails
+		// if the form was parsed before, and `MultipartForm` and the form
+		// methods fail after it was called.
+		MultipartReader() (*multipart.Reader, error)
+
+		// BindFiles returns all files of the multipart form grouped by field
+		// name, e.g. for generic upload handlers.
+		BindFiles() (map[string][]*multipart.FileHeader, error)
+
 		// Cookie returns the named cookie provided in the request.
 		Cookie(name string) (*http.Cookie, error)
 
 		// SetCookie adds a `Set-Cookie` header in HTTP response.
 		SetCookie(cookie *http.Cookie)
 
+		// SignedCookie returns the named cookie provided in the request after
+		// verifying the signature added by `SetSignedCookie` with secret. The
+		// returned cookie's value has the signature removed. It returns
+		// `ErrCookieNotFound` or `ErrCookieTampered`.
+		SignedCookie(name string, secret []byte) (*http.Cookie, error)
+
+		// SetSignedCookie adds a `Set-Cookie` header in HTTP response with an
+		// HMAC-SHA256 signature of the cookie's name and value using secret
+		// appended to the value.
+		SetSignedCookie(cookie *http.Cookie, secret []byte)
+
+		// Session returns the session of the request, or nil if the Sessions
+		// middleware is not used.
+		Session() *Session
+
+		// SetFlash stores a one-shot message under key, which is available to
+		// the next request via `Flashes`, e.g. after a redirect. Messages are
+		// stored in a cookie signed with `Mux#FlashSecret`, which is written
+		// just before the response header. It returns ErrFlashSecretNotSet
+		// if the secret is empty.
+		SetFlash(key, message string) error
+
+		// Flashes returns the messages stored by the previous request with
+		// `SetFlash` and removes them, so that they are only shown once. The
+		// messages are nil if there are none or the cookie was tampered with.
+		// It returns ErrFlashSecretNotSet if `Mux#FlashSecret` is empty.
+		Flashes() (map[string]string, error)
+
 		// Cookies returns the HTTP cookies sent with the request.
 		Cookies() []*http.Cookie
 
@@ -83,14 +196,52 @@ type (
 		// Set saves data in the context.
 		Set(key string, val interface{})
 
+		// Attr retrieves the attribute stored under key from the context.
+		Attr(key AttrKey) interface{}
+
+		// SetAttr stores an attribute under key in the context. Unlike the
+		// string keys of `Set`, AttrKeys of different packages never collide.
+		SetAttr(key AttrKey, val interface{})
+
 		// Bind binds the request body into provided type `i`. The default Binder
-		// does it based on Content-Type header.
+		// does it based on Content-Type header. If a Sanitizer is registered,
+		// string fields tagged with `sanitize:"html"` are sanitized afterwards.
+		// If a Validator is registered, `i` is validated afterwards, see
+		// `Validate`.
 		Bind(i interface{}) error
 
-		// Render renders a template with data and sends a text/html response with status
-		// code. Renderer must be registered using `mux.Renderer`.
+		// Validate validates provided `i`. It is usually called after `Context#Bind()`.
+		// Validation errors are returned as `ErrUnprocessableEntity` with the
+		// validator's message. Validator must be registered using `mux.Validator`.
+		Validate(i interface{}) error
+
+		// BindAll binds the request body, query params, headers and path params
+		// into the struct `i` in one call, see `DefaultBinder#BindAll()` for
+		// their precedence. It uses `Mux#Binder` if it implements AllBinder and
+		// a DefaultBinder otherwise. Sanitization and validation apply like for
+		// `Bind`.
+		BindAll(i interface{}) error
+
+		// BindPathParams binds the path params into the fields of `i` tagged with
+		// `param`, e.g. `param:"id"`.
+		BindPathParams(i interface{}) error
+
+		// BindHeaders binds the request headers into the fields of `i` tagged with
+		// `header`, e.g. `header:"X-Request-ID"`.
+		BindHeaders(i interface{}) error
+
+		// Render renders a template with data and sends a response with status code.
+		// The Content-Type is derived from the template name's extension: ".xml"
+		// is sent as application/xml, ".txt" as text/plain and anything else as
+		// text/html. Renderer must be registered using `mux.Renderer`.
 		Render(code int, name string, data interface{}) error
 
+		// RenderWithLayout renders the named template into the "content" block of
+		// the layout template and sends a response with status code and the
+		// Content-Type derived from the layout name like `Render` does. The
+		// layout is ignored if the registered Renderer isn't a `LayoutRenderer`.
+		RenderWithLayout(code int, layout, name string, data interface{}) error
+
 		// HTML sends an HTTP response with status code.
 		HTML(code int, html string) error
 
@@ -103,10 +254,47 @@ type (
 		// JSON sends a JSON response with status code.
 		JSON(code int, i interface{}) error
 
+		// JSONStream sends a JSON response with status code, encoding i directly
+		// to the response instead of marshaling it into a separate buffer
+		// first, which saves memory for large values. Unlike with `JSON`, the
+		// status code has already been sent if encoding fails, so the error
+		// can't be turned into an error response anymore.
+		JSONStream(code int, i interface{}) error
+
+		// JSONStreamPretty sends a JSON response with status code like
+		// `JSONStream`, indented with indent.
+		JSONStreamPretty(code int, i interface{}, indent string) error
+
+		// JSONArrayStream starts a JSON response with status code whose array
+		// elements are written one by one with the returned writer, which has to
+		// be closed to end the array.
+		JSONArrayStream(code int) (*JSONArrayWriter, error)
+
+		// Multipart starts a multipart response with status code whose parts
+		// are written one by one with the returned writer, which has to be
+		// closed to end the response. The content type is multipart/mixed,
+		// unless another multipart type, e.g. `MIMEMultipartByteranges`, was
+		// set on the response before. The boundary is added to it.
+		Multipart(code int) (*MultipartWriter, error)
+
+		// XML sends an XML response with status code.
+		XML(code int, i interface{}) error
+
+		// Negotiate sends i as JSON or XML, whichever the Accept header of the
+		// request prefers, with status code. If a template name is given and a
+		// Renderer is registered, HTML rendered with i as data is offered as well.
+		// JSON is sent if the request has no preference. If the Accept header
+		// doesn't allow any of them, `ErrNotAcceptable` is returned.
+		Negotiate(code int, i interface{}, template ...string) error
+
 		// Blob sends a blob response with status code and content type.
 		Blob(code int, contentType string, b []byte) error
 
-		// Stream sends a streaming response with status code and content type.
+		// Stream sends a streaming response with status code and content type. It
+		// stops reading r as soon as a write fails or `StdContext()` is done
+		// and returns `ErrClientDisconnected` if the client has gone away,
+		// which the default HTTP error handler ignores. Reads blocking on r are
+		// not interrupted, so r should observe the request context itself.
 		Stream(code int, contentType string, r io.Reader) error
 
 		// File sends a response with the content of the file.
@@ -119,12 +307,40 @@ type (
 		// Inline sends a response as inline, opening the file in the browser.
 		Inline(file string, name string) error
 
-		// NoContent sends a response with no body and a status code.
+		// NoContent sends a response with no body and a status code. The
+		// Content-Type and Content-Length headers are removed, as well as the
+		// other headers describing the body for 304 responses.
 		NoContent(code int) error
 
+		// Created sends a 201 response with no body and the Location header set to
+		// the provided URL.
+		Created(location string) error
+
+		// Accepted sends a 202 response with no body.
+		Accepted() error
+
+		// NoContent204 sends a 204 response with no body.
+		NoContent204() error
+
 		// Redirect redirects the request to a provided URL with status code.
 		Redirect(code int, url string) error
 
+		// RedirectTo redirects the request with status code to the path of the
+		// route named routeName with params, see `Mux#Reverse()`. Passing
+		// `PreserveQuery` along with the params appends the query string of
+		// the request. It fails if there's no route named routeName.
+		RedirectTo(code int, routeName string, params ...interface{}) error
+
+		// Forward dispatches the request internally to the handler of the route
+		// registered for method and path, e.g. to serve a default document,
+		// without a round-trip to the client. A query string in path replaces
+		// the query of the request. Middleware added with `Pre()`, `Use()` and
+		// `UseMatched()` is not run again, but the route-level and group
+		// middleware of the route forwarded to is. It returns
+		// `ErrTooManyForwards` if the request was forwarded too often, which
+		// usually means that routes forward to each other in a loop.
+		Forward(method, path string) error
+
 		// Error invokes the registered HTTP error handler. Generally used by middleware.
 		Error(err error)
 
@@ -133,6 +349,23 @@ type (
 
 		// SetHandler sets the matched handler by router.
 		SetHandler(h HandlerFunc)
+
+		// Mux returns the `Mux` instance.
+		Mux() *Mux
+	}
+
+	// JSONArrayWriter writes the elements of a streamed JSON array response.
+	JSONArrayWriter struct {
+		response *Response
+		enc      *json.Encoder
+		n        int
+		closed   bool
+	}
+
+	// MultipartWriter writes the parts of a streamed multipart response.
+	MultipartWriter struct {
+		response *Response
+		mw       *multipart.Writer
 	}
 
 	context struct {
@@ -143,14 +376,61 @@ type (
 		pvalues  []string
 		query    url.Values
 		handler  HandlerFunc
+		route    *Route
 		store    map[string]interface{}
+		attrs    map[AttrKey]interface{}
+		links    []string
+		body     []byte
+		bodyErr  error
+		bodyRead bool
+		forwards int
+		flash    *flash
 		mux      *Mux
 	}
+
+	// flash holds the flash messages of a request.
+	flash struct {
+		in   map[string]string // Read from the request
+		out  map[string]string // Set for the next request
+		read bool
+	}
+
+	// AttrKey is a key of request attributes, see `Context#SetAttr()`. Like
+	// context.Context keys, each key created with `NewAttrKey` is only equal to
+	// itself, even if another key has the same name.
+	AttrKey struct {
+		*attrKey
+	}
+
+	attrKey struct {
+		name string
+	}
+
+	// preserveQuery is the type of PreserveQuery.
+	preserveQuery struct{}
+
+	// readCloser combines a Reader with the Closer of another one.
+	readCloser struct {
+		io.Reader
+		io.Closer
+	}
+
+	// errReader is an io.Reader which always fails with err.
+	errReader struct {
+		err error
+	}
 )
 
+// PreserveQuery makes `Context#RedirectTo()` append the query string of the
+// request to the redirect URL when passed along with the params.
+var PreserveQuery = preserveQuery{}
+
 const (
-	defaultMemory = 32 << 20 // 32 MB
-	indexPage     = "index.html"
+	flashCookie      = "flash"
+	defaultMemory    = 32 << 20 // 32 MB
+	defaultBodyLimit = 4 << 20  // 4 MB
+	indexPage        = "index.html"
+	maxForwards      = 10
 )
 
 func (c *context) writeContentType(value string) {
@@ -164,6 +444,68 @@ func (c *context) Request() *http.Request {
 	return c.request
 }
 
+func (c *context) BodyReader() io.Reader {
+	if err := c.readBody(); err != nil {
+		return errReader{err}
+	}
+	return bytes.NewReader(c.body)
+}
+
+func (c *context) BodyBytes() ([]byte, error) {
+	if err := c.readBody(); err != nil {
+		return nil, err
+	}
+	return c.body, nil
+}
+
+// readBody reads the request body into memory once and replaces it with a
+// reader over the cached bytes. Bodies exceeding the limit aren't cached and
+// remain readable from the request.
+func (c *context) readBody() error {
+	if c.bodyRead {
+		return c.bodyErr
+	}
+	c.bodyRead = true
+	body := c.request.Body
+	if body == nil {
+		return nil
+	}
+	limit := c.mux.BodyLimit
+	if limit <= 0 {
+		limit = defaultBodyLimit
+	}
+	if c.request.ContentLength > limit {
+		c.bodyErr = ErrStatusRequestEntityTooLarge
+		return c.bodyErr
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		c.bodyErr = err
+		return err
+	}
+	if int64(len(b)) > limit {
+		c.bodyErr = ErrStatusRequestEntityTooLarge
+		c.request.Body = readCloser{io.MultiReader(bytes.NewReader(b), body), body}
+		return c.bodyErr
+	}
+	body.Close()
+	c.body = b
+	c.request.Body = ioutil.NopCloser(bytes.NewReader(b))
+	return nil
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func (c *context) StdContext() stdcontext.Context {
+	return c.request.Context()
+}
+
+func (c *context) SetStdContext(ctx stdcontext.Context) {
+	c.request = c.request.WithContext(ctx)
+}
+
 func (c *context) SetRequest(r *http.Request) {
 	c.request = r
 }
@@ -172,6 +514,68 @@ func (c *context) Response() *Response {
 	return c.response
 }
 
+func (c *context) RealIP() string {
+	return realIP(c.request, c.mux.trustedProxies)
+}
+
+// realIP returns the address of the client r comes from. If the remote address
+// is in trusted, the rightmost address of `X-Forwarded-For` which isn't is
+// returned, as the ones left of it may be forged by the client, or else
+// `X-Real-IP`.
+func realIP(r *http.Request, trusted []*net.IPNet) string {
+	ra := remoteHost(r.RemoteAddr)
+	if !isTrusted(trusted, ra) {
+		return ra
+	}
+	if xff := r.Header.Values(HeaderXForwardedFor); len(xff) > 0 {
+		ips := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(ips) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(ips[i])
+			if i == 0 || !isTrusted(trusted, ip) {
+				return ip
+			}
+		}
+	}
+	if ip := r.Header.Get(HeaderXRealIP); ip != "" {
+		return ip
+	}
+	return ra
+}
+
+// remoteHost returns the host of the remote address addr, which may lack a
+// port.
+func remoteHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+}
+
+func isTrusted(trusted []*net.IPNet, addr string) bool {
+	if len(trusted) == 0 {
+		return false
+	}
+	ip := net.ParseIP(addr)
+	return ip != nil && containsIP(trusted, ip)
+}
+
+func (c *context) AddLink(rel, href string) {
+	if c.links == nil {
+		c.response.Before(func() {
+			c.response.Header().Set(HeaderLink, strings.Join(c.links, ", "))
+		})
+	}
+	c.links = append(c.links, fmt.Sprintf("<%s>; rel=%q", href, rel))
+}
+
+func (c *context) SetTrailer(key, value string) {
+	c.response.Header().Set(http.TrailerPrefix+key, value)
+}
+
+func (c *context) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return c.response.Hijack()
+}
+
 func (c *context) Path() string {
 	return c.path
 }
@@ -180,6 +584,10 @@ func (c *context) SetPath(p string) {
 	c.path = p
 }
 
+func (c *context) RouteInfo() *Route {
+	return c.route
+}
+
 func (c *context) Param(name string) string {
 	for i, n := range c.pnames {
 		if i < len(c.pvalues) {
@@ -191,6 +599,17 @@ func (c *context) Param(name string) string {
 	return ""
 }
 
+func (c *context) ParamWildcard() string {
+	v := c.Param("*")
+	if c.request.URL.RawPath == "" {
+		return v
+	}
+	if p, err := url.PathUnescape(v); err == nil {
+		return p
+	}
+	return v
+}
+
 func (c *context) ParamNames() []string {
 	return c.pnames
 }
@@ -207,6 +626,16 @@ func (c *context) SetParamValues(values ...string) {
 	c.pvalues = values
 }
 
+func (c *context) Params() map[string]string {
+	params := make(map[string]string, len(c.pnames))
+	for i, name := range c.pnames {
+		if i < len(c.pvalues) {
+			params[name] = c.pvalues[i]
+		}
+	}
+	return params
+}
+
 func (c *context) QueryParam(name string) string {
 	if c.query == nil {
 		c.query = c.request.URL.Query()
@@ -231,7 +660,7 @@ func (c *context) FormValue(name string) string {
 
 func (c *context) FormParams() (url.Values, error) {
 	if strings.HasPrefix(c.request.Header.Get(HeaderContentType), MIMEMultipartForm) {
-		if err := c.request.ParseMultipartForm(defaultMemory); err != nil {
+		if err := c.request.ParseMultipartForm(c.multipartMemory()); err != nil {
 			return nil, err
 		}
 	} else {
@@ -248,10 +677,34 @@ func (c *context) FormFile(name string) (*multipart.FileHeader, error) {
 }
 
 func (c *context) MultipartForm() (*multipart.Form, error) {
-	err := c.request.ParseMultipartForm(defaultMemory)
+	return c.MultipartFormWithLimit(c.multipartMemory())
+}
+
+func (c *context) MultipartFormWithLimit(maxMemory int64) (*multipart.Form, error) {
+	err := c.request.ParseMultipartForm(maxMemory)
 	return c.request.MultipartForm, err
 }
 
+func (c *context) MultipartReader() (*multipart.Reader, error) {
+	return c.request.MultipartReader()
+}
+
+// multipartMemory returns the memory limit for parsing multipart forms.
+func (c *context) multipartMemory() int64 {
+	if c.mux.MultipartMemoryLimit > 0 {
+		return c.mux.MultipartMemoryLimit
+	}
+	return defaultMemory
+}
+
+func (c *context) BindFiles() (map[string][]*multipart.FileHeader, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	return form.File, nil
+}
+
 func (c *context) Cookie(name string) (*http.Cookie, error) {
 	return c.request.Cookie(name)
 }
@@ -260,6 +713,99 @@ func (c *context) SetCookie(cookie *http.Cookie) {
 	http.SetCookie(c.Response(), cookie)
 }
 
+func (c *context) SignedCookie(name string, secret []byte) (*http.Cookie, error) {
+	cookie, err := c.request.Cookie(name)
+	if err != nil {
+		return nil, ErrCookieNotFound
+	}
+	i := strings.LastIndexByte(cookie.Value, '.')
+	if i < 0 {
+		return nil, ErrCookieTampered
+	}
+	value, sig := cookie.Value[:i], cookie.Value[i+1:]
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, cookieSignature(name, value, secret)) {
+		return nil, ErrCookieTampered
+	}
+	cookie.Value = value
+	return cookie, nil
+}
+
+func (c *context) SetSignedCookie(cookie *http.Cookie, secret []byte) {
+	signed := *cookie
+	signed.Value = cookie.Value + "." + base64.RawURLEncoding.EncodeToString(cookieSignature(cookie.Name, cookie.Value, secret))
+	c.SetCookie(&signed)
+}
+
+// cookieSignature returns the HMAC-SHA256 of the cookie name and value.
+func cookieSignature(name, value string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(name + "=" + value))
+	return mac.Sum(nil)
+}
+
+func (c *context) Session() *Session {
+	s, _ := c.Get(SessionKey).(*Session)
+	return s
+}
+
+func (c *context) SetFlash(key, message string) error {
+	f, err := c.flashes()
+	if err != nil {
+		return err
+	}
+	if f.out == nil {
+		f.out = make(map[string]string)
+	}
+	f.out[key] = message
+	return nil
+}
+
+func (c *context) Flashes() (map[string]string, error) {
+	f, err := c.flashes()
+	if err != nil {
+		return nil, err
+	}
+	if f.read {
+		return f.in, nil
+	}
+	f.read = true
+	cookie, err := c.SignedCookie(flashCookie, c.mux.FlashSecret)
+	if err != nil {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil || json.Unmarshal(b, &f.in) != nil {
+		f.in = nil
+	}
+	return f.in, nil
+}
+
+// flashes returns the flash messages of the request, registering the hook
+// writing them on first use.
+func (c *context) flashes() (*flash, error) {
+	if c.flash != nil {
+		return c.flash, nil
+	}
+	if len(c.mux.FlashSecret) == 0 {
+		return nil, ErrFlashSecretNotSet
+	}
+	f := new(flash)
+	c.flash = f
+	c.response.Before(func() {
+		cookie := &http.Cookie{Name: flashCookie, Path: "/", HttpOnly: true}
+		if len(f.out) > 0 {
+			b, _ := json.Marshal(f.out)
+			cookie.Value = base64.RawURLEncoding.EncodeToString(b)
+			c.SetSignedCookie(cookie, c.mux.FlashSecret)
+		} else if _, err := c.request.Cookie(flashCookie); f.read && err == nil {
+			cookie.MaxAge = -1
+			c.SetCookie(cookie)
+		}
+	})
+	return f, nil
+}
+
 func (c *context) Cookies() []*http.Cookie {
 	return c.request.Cookies()
 }
@@ -275,8 +821,91 @@ func (c *context) Set(key string, val interface{}) {
 	c.store[key] = val
 }
 
+// NewAttrKey returns a new AttrKey. The name is only used for debugging.
+//
+//	var userKey = route.NewAttrKey("user")
+func NewAttrKey(name string) AttrKey {
+	return AttrKey{&attrKey{name}}
+}
+
+// String returns the name of the key.
+func (k AttrKey) String() string {
+	if k.attrKey == nil {
+		return ""
+	}
+	return k.name
+}
+
+func (c *context) Attr(key AttrKey) interface{} {
+	return c.attrs[key]
+}
+
+func (c *context) SetAttr(key AttrKey, val interface{}) {
+	if c.attrs == nil {
+		c.attrs = make(map[AttrKey]interface{})
+	}
+	c.attrs[key] = val
+}
+
 func (c *context) Bind(i interface{}) error {
-	return c.mux.Binder.Bind(i, c)
+	if err := c.mux.Binder.Bind(i, c); err != nil {
+		return err
+	}
+	if c.mux.Sanitizer != nil {
+		sanitize(reflect.ValueOf(i), c.mux.Sanitizer)
+	}
+	if c.mux.Validator == nil {
+		return nil
+	}
+	return c.Validate(i)
+}
+
+func (c *context) BindAll(i interface{}) error {
+	b, ok := c.mux.Binder.(AllBinder)
+	if !ok {
+		b = new(DefaultBinder)
+	}
+	if err := b.BindAll(i, c); err != nil {
+		return err
+	}
+	if c.mux.Sanitizer != nil {
+		sanitize(reflect.ValueOf(i), c.mux.Sanitizer)
+	}
+	if c.mux.Validator == nil {
+		return nil
+	}
+	return c.Validate(i)
+}
+
+func (c *context) Validate(i interface{}) error {
+	if c.mux.Validator == nil {
+		return ErrValidatorNotRegistered
+	}
+	err := c.mux.Validator.Validate(i)
+	if err == nil {
+		return nil
+	}
+	if he, ok := err.(*HTTPError); ok {
+		return he
+	}
+	return NewHTTPError(http.StatusUnprocessableEntity, err.Error()).SetInternal(err)
+}
+
+func (c *context) BindPathParams(i interface{}) error {
+	return c.defaultBinder().BindPathParams(c, i)
+}
+
+func (c *context) BindHeaders(i interface{}) error {
+	return c.defaultBinder().BindHeaders(c, i)
+}
+
+// defaultBinder returns the registered Binder if it is a `DefaultBinder`, so
+// its configuration is used, or a new one otherwise.
+func (c *context) defaultBinder() *DefaultBinder {
+	if b, ok := c.mux.Binder.(*DefaultBinder); ok {
+		return b
+	}
+	return new(DefaultBinder)
 }
 
 func (c *context) Render(code int, name string, data interface{}) (err error) {
@@ -287,7 +916,31 @@ func (c *context) Render(code int, name string, data interface{}) (err error) {
 	if err = c.mux.Renderer.Render(buf, name, data, c); err != nil {
 		return
 	}
-	return c.HTMLBlob(code, buf.Bytes())
+	return c.Blob(code, templateContentType(name), buf.Bytes())
+}
+
+func (c *context) RenderWithLayout(code int, layout, name string, data interface{}) (err error) {
+	lr, ok := c.mux.Renderer.(LayoutRenderer)
+	if !ok {
+		return c.Render(code, name, data)
+	}
+	buf := new(bytes.Buffer)
+	if err = lr.RenderLayout(buf, layout, name, data, c); err != nil {
+		return
+	}
+	return c.Blob(code, templateContentType(layout), buf.Bytes())
+}
+
+// templateContentType returns the Content-Type of the output of template name.
+func templateContentType(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".xml":
+		return MIMEApplicationXMLCharsetUTF8
+	case ".txt":
+		return MIMETextPlainCharsetUTF8
+	default:
+		return MIMETextHTMLCharsetUTF8
+	}
 }
 
 func (c *context) HTML(code int, html string) (err error) {
@@ -307,23 +960,198 @@ func (c *context) JSON(code int, i interface{}) (err error) {
 	if c.mux.Debug || pretty {
 		return c.jsonPretty(code, i, "  ")
 	}
-	b, err := json.Marshal(i)
-	if err != nil {
-		return
-	}
-	return c.jsonBlob(code, b)
+	return c.jsonPretty(code, i, "")
 }
 
+// jsonPretty sends i encoded by the JSONSerializer with status code. The
+// status is only sent once the serializer writes, so that encoding errors can
+// still be sent by the HTTP error handler.
 func (c *context) jsonPretty(code int, i interface{}, indent string) (err error) {
-	b, err := json.MarshalIndent(i, "", indent)
+	header := c.response.Header()
+	ctype := header.Get(HeaderContentType)
+	if ctype == "" {
+		header.Set(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
+	}
+	c.response.pendingStatus = code
+	err = c.mux.jsonSerializer().Serialize(c, i, indent)
+	c.response.pendingStatus = 0
+	if err != nil && !c.response.Committed && ctype == "" {
+		header.Del(HeaderContentType)
+	}
+	return
+}
+
+func (c *context) JSONStream(code int, i interface{}) error {
+	return c.JSONStreamPretty(code, i, "")
+}
+
+func (c *context) JSONStreamPretty(code int, i interface{}, indent string) error {
+	c.writeContentType(MIMEApplicationJSONCharsetUTF8)
+	c.response.WriteHeader(code)
+	enc := json.NewEncoder(c.response)
+	if indent != "" {
+		enc.SetIndent("", indent)
+	}
+	return enc.Encode(i)
+}
+
+func (c *context) JSONArrayStream(code int) (*JSONArrayWriter, error) {
+	c.writeContentType(MIMEApplicationJSONCharsetUTF8)
+	c.response.WriteHeader(code)
+	if _, err := c.response.Write([]byte{'['}); err != nil {
+		return nil, err
+	}
+	return &JSONArrayWriter{response: c.response, enc: json.NewEncoder(c.response)}, nil
+}
+
+// jsonArrayFlushInterval is the number of elements after which a JSONArrayWriter
+// flushes the response.
+const jsonArrayFlushInterval = 100
+
+// Write writes item as the next element of the array.
+func (w *JSONArrayWriter) Write(item interface{}) error {
+	if w.closed {
+		return errors.New("write to closed JSON array")
+	}
+	if w.n > 0 {
+		if _, err := w.response.Write([]byte{','}); err != nil {
+			return err
+		}
+	}
+	if err := w.enc.Encode(item); err != nil {
+		return err
+	}
+	w.n++
+	if w.n%jsonArrayFlushInterval == 0 {
+		w.response.Flush()
+	}
+	return nil
+}
+
+// Close ends the array and flushes the response.
+func (w *JSONArrayWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if _, err := w.response.Write([]byte{']'}); err != nil {
+		return err
+	}
+	w.response.Flush()
+	return nil
+}
+
+func (c *context) Multipart(code int) (*MultipartWriter, error) {
+	mw := multipart.NewWriter(c.response)
+	ctype := MIMEMultipartMixed
+	if mt, _, err := mime.ParseMediaType(c.response.Header().Get(HeaderContentType)); err == nil && strings.HasPrefix(mt, "multipart/") {
+		ctype = mt
+	}
+	c.response.Header().Set(HeaderContentType, mime.FormatMediaType(ctype, map[string]string{"boundary": mw.Boundary()}))
+	c.response.WriteHeader(code)
+	return &MultipartWriter{response: c.response, mw: mw}, nil
+}
+
+// CreatePart starts a new part with header and returns a writer for its body.
+// The previous parts are flushed to the client.
+func (w *MultipartWriter) CreatePart(header textproto.MIMEHeader) (io.Writer, error) {
+	w.response.Flush()
+	return w.mw.CreatePart(header)
+}
+
+// Boundary returns the boundary separating the parts.
+func (w *MultipartWriter) Boundary() string {
+	return w.mw.Boundary()
+}
+
+// Close writes the closing boundary and flushes the response.
+func (w *MultipartWriter) Close() error {
+	if err := w.mw.Close(); err != nil {
+		return err
+	}
+	w.response.Flush()
+	return nil
+}
+
+func (c *context) XML(code int, i interface{}) (err error) {
+	b, err := xml.Marshal(i)
 	if err != nil {
 		return
 	}
-	return c.jsonBlob(code, b)
+	return c.Blob(code, MIMEApplicationXMLCharsetUTF8, append([]byte(xml.Header), b...))
+}
+
+func (c *context) Negotiate(code int, i interface{}, template ...string) error {
+	offers := []string{MIMEApplicationJSON, MIMEApplicationXML, MIMETextXML}
+	if len(template) > 0 && c.mux.Renderer != nil {
+		offers = append(offers, MIMETextHTML)
+	}
+	accept := c.request.Header.Get(HeaderAccept)
+	ctype := MIMEApplicationJSON
+	if accept != "" {
+		if ctype = NegotiateContentType(accept, offers); ctype == "" {
+			return ErrNotAcceptable
+		}
+	}
+	switch ctype {
+	case MIMEApplicationXML, MIMETextXML:
+		return c.XML(code, i)
+	case MIMETextHTML:
+		return c.Render(code, template[0], i)
+	default:
+		return c.JSON(code, i)
+	}
 }
 
-func (c *context) jsonBlob(code int, b []byte) (err error) {
-	return c.Blob(code, MIMEApplicationJSONCharsetUTF8, b)
+// NegotiateContentType returns the offer the Accept header value prefers most,
+// or an empty string if none is acceptable. Ties are broken by the more specific
+// media range and then by the order of offers.
+func NegotiateContentType(accept string, offers []string) string {
+	best, bestQ, bestSpec := "", 0.0, -1
+	for _, offer := range offers {
+		q, spec := 0.0, -1
+		for _, r := range strings.Split(accept, ",") {
+			mediaRange, rq := parseMediaRange(r)
+			s := matchMediaRange(mediaRange, offer)
+			if s > spec {
+				q, spec = rq, s
+			}
+		}
+		if q > bestQ || (q == bestQ && q > 0 && spec > bestSpec) {
+			best, bestQ, bestSpec = offer, q, spec
+		}
+	}
+	return best
+}
+
+// parseMediaRange parses one media range of an Accept header value into the
+// media range and its quality value.
+func parseMediaRange(s string) (string, float64) {
+	parts := strings.Split(s, ";")
+	q := 1.0
+	for _, p := range parts[1:] {
+		p = strings.TrimSpace(p)
+		if strings.HasPrefix(p, "q=") {
+			if v, err := strconv.ParseFloat(p[2:], 64); err == nil {
+				q = v
+			}
+		}
+	}
+	return strings.ToLower(strings.TrimSpace(parts[0])), q
+}
+
+// matchMediaRange returns how specific mediaRange matches mime: 2 for an exact
+// match, 1 for a subtype wildcard, 0 for "*/*" and -1 if it doesn't match.
+func matchMediaRange(mediaRange, mime string) int {
+	switch {
+	case mediaRange == mime:
+		return 2
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mime, mediaRange[:len(mediaRange)-1]):
+		return 1
+	}
+	return -1
 }
 
 func (c *context) Blob(code int, contentType string, b []byte) (err error) {
@@ -336,33 +1164,84 @@ func (c *context) Blob(code int, contentType string, b []byte) (err error) {
 func (c *context) Stream(code int, contentType string, r io.Reader) (err error) {
 	c.writeContentType(contentType)
 	c.response.WriteHeader(code)
-	_, err = io.Copy(c.response, r)
-	return
+	ctx := c.StdContext()
+	buf := make([]byte, 32*1024)
+	for {
+		if err = ctx.Err(); err != nil {
+			return streamContextError(err)
+		}
+		n, rerr := r.Read(buf)
+		if n > 0 {
+			if _, err = c.response.Write(buf[:n]); err != nil {
+				if isClientDisconnect(err) {
+					return ErrClientDisconnected
+				}
+				if ctx.Err() != nil {
+					return streamContextError(ctx.Err())
+				}
+				return
+			}
+		}
+		if rerr == io.EOF {
+			return nil
+		}
+		if rerr != nil {
+			return rerr
+		}
+	}
+}
+
+// streamContextError returns the error of a stream aborted because the request
+// context is done with err.
+func streamContextError(err error) error {
+	if err == stdcontext.Canceled {
+		return ErrClientDisconnected
+	}
+	return err
+}
+
+// isClientDisconnect reports whether err is caused by the client closing the
+// connection.
+func isClientDisconnect(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
 }
 
 func (c *context) File(file string) (err error) {
 	f, err := os.Open(file)
 	if err != nil {
-		return NotFoundHandler(c)
+		return notFound(c)
 	}
 	defer f.Close()
 
-	fi, _ := f.Stat()
+	fi, err := f.Stat()
+	if err != nil {
+		return
+	}
 	if fi.IsDir() {
 		file = filepath.Join(file, indexPage)
 		f, err = os.Open(file)
 		if err != nil {
-			return NotFoundHandler(c)
+			return notFound(c)
 		}
 		defer f.Close()
 		if fi, err = f.Stat(); err != nil {
 			return
 		}
 	}
+	setETag(c.Response().Header(), fi)
+	// ServeContent handles Range, If-Modified-Since and If-None-Match requests.
 	http.ServeContent(c.Response(), c.Request(), fi.Name(), fi.ModTime(), f)
 	return
 }
 
+// setETag sets an ETag derived from the modification time and size of the
+// file described by fi, unless the handler set one already.
+func setETag(header http.Header, fi os.FileInfo) {
+	if header.Get(HeaderETag) == "" {
+		header.Set(HeaderETag, fmt.Sprintf(`"%x-%x"`, fi.ModTime().UnixNano(), fi.Size()))
+	}
+}
+
 func (c *context) Attachment(file, name string) error {
 	return c.contentDisposition(file, name, "attachment")
 }
@@ -377,10 +1256,37 @@ func (c *context) contentDisposition(file, name, dispositionType string) error {
 }
 
 func (c *context) NoContent(code int) error {
+	removeBodyHeaders(c.response.Header(), code)
 	c.response.WriteHeader(code)
 	return nil
 }
 
+// removeBodyHeaders removes the headers describing the body from the header
+// of a response with status code and no body.
+func removeBodyHeaders(header http.Header, code int) {
+	header.Del(HeaderContentType)
+	header.Del(HeaderContentLength)
+	if code == http.StatusNotModified {
+		header.Del(HeaderContentEncoding)
+		header.Del(HeaderContentLanguage)
+		header.Del(HeaderContentRange)
+		header.Del(HeaderContentDisposition)
+	}
+}
+
+func (c *context) Created(location string) error {
+	c.response.Header().Set(HeaderLocation, location)
+	return c.NoContent(http.StatusCreated)
+}
+
+func (c *context) Accepted() error {
+	return c.NoContent(http.StatusAccepted)
+}
+
+func (c *context) NoContent204() error {
+	return c.NoContent(http.StatusNoContent)
+}
+
 func (c *context) Redirect(code int, url string) error {
 	if code < 300 || code > 308 {
 		return ErrInvalidRedirectCode
@@ -390,6 +1296,58 @@ func (c *context) Redirect(code int, url string) error {
 	return nil
 }
 
+func (c *context) RedirectTo(code int, routeName string, params ...interface{}) error {
+	if code < 300 || code > 308 {
+		return ErrInvalidRedirectCode
+	}
+	values := make([]interface{}, 0, len(params))
+	preserve := false
+	for _, p := range params {
+		if _, ok := p.(preserveQuery); ok {
+			preserve = true
+		} else {
+			values = append(values, p)
+		}
+	}
+	url := c.mux.Reverse(routeName, values...)
+	if url == "" {
+		return fmt.Errorf("route: no route named %q", routeName)
+	}
+	if q := c.request.URL.RawQuery; preserve && q != "" {
+		if strings.IndexByte(url, '?') >= 0 {
+			url += "&" + q
+		} else {
+			url += "?" + q
+		}
+	}
+	return c.Redirect(code, url)
+}
+
+func (c *context) Forward(method, path string) error {
+	if c.forwards >= maxForwards {
+		return ErrTooManyForwards
+	}
+	c.forwards++
+	u, err := url.Parse(path)
+	if err != nil {
+		return err
+	}
+	r := c.request.Clone(c.request.Context())
+	r.Method = method
+	r.URL.Path = u.Path
+	r.URL.RawPath = u.RawPath
+	if u.RawQuery != "" {
+		r.URL.RawQuery = u.RawQuery
+	}
+	r.RequestURI = r.URL.RequestURI()
+	c.request = r
+	c.query = nil
+	c.handler = NotFoundHandler
+	c.route = nil
+	c.mux.router.find(method, getPath(r), c)
+	return c.handler(c)
+}
+
 func (c *context) Error(err error) {
 	c.mux.HTTPErrorHandler(err, c)
 }
@@ -402,14 +1360,34 @@ func (c *context) SetHandler(h HandlerFunc) {
 	c.handler = h
 }
 
+func (c *context) Mux() *Mux {
+	return c.mux
+}
+
 func (c *context) reset(r *http.Request, w http.ResponseWriter) {
 	c.request = r
 	c.response.reset(w)
 	c.query = nil
 	c.handler = NotFoundHandler
-	c.store = nil
+	c.route = nil
+	// Clear instead of reallocating the store, so that pooled contexts don't
+	// allocate per request
+	for k := range c.store {
+		delete(c.store, k)
+	}
+	for k := range c.attrs {
+		delete(c.attrs, k)
+	}
+	c.links = nil
+	c.body = nil
+	c.bodyErr = nil
+	c.bodyRead = false
+	c.forwards = 0
+	c.flash = nil
 	c.path = ""
 	c.pnames = nil
 	// NOTE: Don't reset because it has to have length c.mux.maxParam at all times
-	// c.pvalues = nil
+	for i := range c.pvalues {
+		c.pvalues[i] = ""
+	}
 }
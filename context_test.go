@@ -2,13 +2,23 @@ package route
 
 import (
 	"bytes"
+	stdcontext "context"
+	"encoding/json"
+	"encoding/xml"
 	"errors"
+	htmltemplate "html/template"
 	"io"
+	"io/ioutil"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
 	"net/url"
+	"os"
+	"reflect"
 	"strings"
+	"syscall"
 	"testing"
 	"text/template"
 	"time"
@@ -51,9 +61,27 @@ func TestContext(t *testing.T) {
 	err := c.Render(http.StatusOK, "hello", "Jon Snow")
 	if assert.NoError(err) {
 		assert.Equal(http.StatusOK, rec.Code)
+		assert.Equal(MIMETextHTMLCharsetUTF8, rec.Header().Get(HeaderContentType))
 		assert.Equal("Hello, Jon Snow!", rec.Body.String())
 	}
 
+	// Content-Type by template extension
+	tmpl.templates = template.Must(tmpl.templates.New("hello.xml").Parse("<hello>{{.}}</hello>"))
+	template.Must(tmpl.templates.New("hello.txt").Parse("Hello, {{.}}!"))
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec).(*context)
+	err = c.Render(http.StatusOK, "hello.xml", "Jon Snow")
+	if assert.NoError(err) {
+		assert.Equal(MIMEApplicationXMLCharsetUTF8, rec.Header().Get(HeaderContentType))
+		assert.Equal("<hello>Jon Snow</hello>", rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec).(*context)
+	err = c.Render(http.StatusOK, "hello.txt", "Jon Snow")
+	if assert.NoError(err) {
+		assert.Equal(MIMETextPlainCharsetUTF8, rec.Header().Get(HeaderContentType))
+	}
+
 	c.mux.Renderer = nil
 	err = c.Render(http.StatusOK, "hello", "Jon Snow")
 	assert.Error(err)
@@ -164,14 +192,112 @@ func TestContext(t *testing.T) {
 	c.SetParamValues("bar")
 	c.Set("foe", "ban")
 	c.query = url.Values(map[string][]string{"fon": {"baz"}})
+	store := c.store
 	c.reset(req, httptest.NewRecorder())
 	assert.Equal(0, len(c.ParamValues()))
 	assert.Equal(0, len(c.ParamNames()))
+	for _, v := range c.pvalues {
+		assert.Empty(v)
+	}
 	assert.Equal(0, len(c.store))
+	assert.Equal(reflect.ValueOf(store).Pointer(), reflect.ValueOf(c.store).Pointer())
 	assert.Equal("", c.Path())
 	assert.Equal(0, len(c.QueryParams()))
 }
 
+func TestContextRenderWithLayout(t *testing.T) {
+	e := NewServeMux(WithRenderer(NewDefaultTemplateRenderer("testdata/templates/*.html", htmltemplate.FuncMap{
+		"upper": strings.ToUpper,
+	})))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	assert := assert.New(t)
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		err := c.RenderWithLayout(http.StatusOK, "layout.html", "page.html", "Jon Snow")
+		if assert.NoError(err) {
+			assert.Equal(http.StatusOK, rec.Code)
+			assert.Equal(MIMETextHTMLCharsetUTF8, rec.Header().Get(HeaderContentType))
+			assert.Equal("<html><title>Mux</title><body><p>Jon Snow</p></body></html>", rec.Body.String())
+		}
+	}
+
+	// Templates can still be rendered on their own
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	err := c.Render(http.StatusOK, "page.html", "Jon Snow")
+	if assert.NoError(err) {
+		assert.Equal("<p>Jon Snow</p>", rec.Body.String())
+	}
+
+	// Unknown content template
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	assert.Error(c.RenderWithLayout(http.StatusOK, "layout.html", "missing.html", nil))
+
+	// Renderer without layout support
+	e.Renderer = &Template{
+		templates: template.Must(template.New("hello").Parse("Hello, {{.}}!")),
+	}
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	err = c.RenderWithLayout(http.StatusOK, "layout.html", "hello", "Jon Snow")
+	if assert.NoError(err) {
+		assert.Equal("Hello, Jon Snow!", rec.Body.String())
+	}
+
+	e.Renderer = nil
+	assert.Equal(ErrRendererNotRegistered, c.RenderWithLayout(http.StatusOK, "layout.html", "hello", nil))
+}
+
+func TestContextSignedCookie(t *testing.T) {
+	e := NewServeMux()
+	secret := []byte("secret")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	cookie := &http.Cookie{Name: "flash", Value: "Saved.", Path: "/"}
+	c.SetSignedCookie(cookie, secret)
+	assert.Equal(t, "Saved.", cookie.Value)
+	signed := rec.Result().Cookies()[0]
+	assert.True(t, strings.HasPrefix(signed.Value, "Saved.."))
+	assert.Equal(t, "/", signed.Path)
+
+	// Valid
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "flash", Value: signed.Value})
+	c = e.NewContext(req, nil)
+	got, err := c.SignedCookie("flash", secret)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Saved.", got.Value)
+	}
+
+	// Wrong secret
+	_, err = c.SignedCookie("flash", []byte("other"))
+	assert.Equal(t, ErrCookieTampered, err)
+
+	// Tampered
+	for _, value := range []string{"Deleted." + signed.Value[len("Saved."):], "Saved.", "unsigned", signed.Value + "x"} {
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: "flash", Value: value})
+		c = e.NewContext(req, nil)
+		_, err = c.SignedCookie("flash", secret)
+		assert.Equal(t, ErrCookieTampered, err, value)
+	}
+
+	// Signed for another name
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "notice", Value: signed.Value})
+	c = e.NewContext(req, nil)
+	_, err = c.SignedCookie("notice", secret)
+	assert.Equal(t, ErrCookieTampered, err)
+
+	// Missing
+	_, err = c.SignedCookie("flash", secret)
+	assert.Equal(t, ErrCookieNotFound, err)
+}
+
 func TestContextCookie(t *testing.T) {
 	e := NewServeMux()
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -253,6 +379,203 @@ func TestContextPathParam(t *testing.T) {
 	assert.Equal(t, "501", c.Param("fid"))
 }
 
+type failingWriter struct {
+	*httptest.ResponseRecorder
+	limit int
+	err   error
+}
+
+func (w *failingWriter) Write(b []byte) (int, error) {
+	if w.Body.Len()+len(b) > w.limit {
+		return 0, w.err
+	}
+	return w.ResponseRecorder.Write(b)
+}
+
+type countingReader struct {
+	reads int
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	r.reads++
+	return len(b), nil
+}
+
+func TestContextStreamWriteError(t *testing.T) {
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	// Client gone
+	w := &failingWriter{ResponseRecorder: httptest.NewRecorder(), limit: 64 * 1024, err: &net.OpError{Op: "write", Err: os.NewSyscallError("write", syscall.EPIPE)}}
+	r := new(countingReader)
+	c := e.NewContext(req, w)
+	assert.Equal(t, ErrClientDisconnected, c.Stream(http.StatusOK, MIMEOctetStream, r))
+	assert.Equal(t, 3, r.reads)
+	assert.Equal(t, 64*1024, w.Body.Len())
+
+	// Other errors
+	failure := errors.New("failure")
+	w = &failingWriter{ResponseRecorder: httptest.NewRecorder(), limit: 0, err: failure}
+	r = new(countingReader)
+	c = e.NewContext(req, w)
+	assert.Equal(t, failure, c.Stream(http.StatusOK, MIMEOctetStream, r))
+	assert.Equal(t, 1, r.reads)
+}
+
+// cancelingReader cancels the request context after reads reads.
+type cancelingReader struct {
+	countingReader
+	cancel func()
+	after  int
+}
+
+func (r *cancelingReader) Read(b []byte) (int, error) {
+	if r.reads+1 == r.after {
+		r.cancel()
+	}
+	return r.countingReader.Read(b)
+}
+
+func TestContextStreamCanceled(t *testing.T) {
+	e := NewServeMux()
+	var logged []error
+	e.ErrorLogger = func(c Context, err error) {
+		logged = append(logged, err)
+	}
+	ctx, cancel := stdcontext.WithCancel(stdcontext.Background())
+	r := &cancelingReader{cancel: cancel, after: 2}
+	e.GET("/", func(c Context) error {
+		return c.Stream(http.StatusOK, MIMEOctetStream, r)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, 2, r.reads)
+	assert.Equal(t, 2*32*1024, rec.Body.Len())
+	assert.Empty(t, logged)
+
+	// Deadline
+	ctx, cancel = stdcontext.WithTimeout(stdcontext.Background(), 0)
+	defer cancel()
+	req = httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	c := e.NewContext(req, httptest.NewRecorder())
+	assert.Equal(t, stdcontext.DeadlineExceeded, c.Stream(http.StatusOK, MIMEOctetStream, new(countingReader)))
+}
+
+func TestContextRealIP(t *testing.T) {
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "89.89.89.89:1654"
+	c := e.NewContext(req, nil)
+	assert.Equal(t, "89.89.89.89", c.RealIP())
+
+	// Headers of untrusted clients are ignored
+	req.Header.Set(HeaderXRealIP, "127.0.0.1")
+	assert.Equal(t, "89.89.89.89", c.RealIP())
+	req.Header.Set(HeaderXForwardedFor, "127.0.0.2")
+	assert.Equal(t, "89.89.89.89", c.RealIP())
+
+	// Remote address without port
+	req.RemoteAddr = "89.89.89.90"
+	assert.Equal(t, "89.89.89.90", c.RealIP())
+	req.RemoteAddr = "[2001:db8::1]"
+	assert.Equal(t, "2001:db8::1", c.RealIP())
+
+	// Trusted proxies
+	assert.NoError(t, e.SetTrustedProxies("10.0.0.0/8", "2001:db8::1"))
+	assert.Equal(t, "127.0.0.2", c.RealIP())
+	req.RemoteAddr = "10.0.0.1:1654"
+	req.Header.Del(HeaderXForwardedFor)
+	assert.Equal(t, "127.0.0.1", c.RealIP())
+	req.Header.Set(HeaderXForwardedFor, "1.1.1.1, 127.0.0.2, 10.0.0.2")
+	assert.Equal(t, "127.0.0.2", c.RealIP())
+	req.Header.Add(HeaderXForwardedFor, "10.0.0.3")
+	assert.Equal(t, "127.0.0.2", c.RealIP())
+	req.Header.Set(HeaderXForwardedFor, "10.0.0.3, 10.0.0.2")
+	assert.Equal(t, "10.0.0.3", c.RealIP())
+
+	assert.EqualError(t, e.SetTrustedProxies("proxy"), `route: invalid IP "proxy"`)
+}
+
+func TestContextAddLink(t *testing.T) {
+	e := NewServeMux()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/users?page=2", nil), rec)
+	c.AddLink("next", "/users?page=3")
+	c.AddLink("prev", "/users?page=1")
+	c.AddLink("first", "https://example.com/users")
+	if assert.NoError(t, c.JSON(http.StatusOK, []string{})) {
+		assert.Equal(t, `</users?page=3>; rel="next", </users?page=1>; rel="prev", <https://example.com/users>; rel="first"`, rec.Header().Get(HeaderLink))
+	}
+}
+
+func TestContextSetTrailer(t *testing.T) {
+	e := NewServeMux()
+	e.GET("/", func(c Context) error {
+		c.SetTrailer("Grpc-Status", "1")
+		if err := c.String(http.StatusOK, "body"); err != nil {
+			return err
+		}
+		c.Response().Flush()
+		c.SetTrailer("Grpc-Status", "0")
+		c.SetTrailer("Grpc-Message", "OK")
+		return nil
+	})
+	s := httptest.NewServer(e)
+	defer s.Close()
+
+	res, err := http.Get(s.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer res.Body.Close()
+	assert.Empty(t, res.Trailer.Get("Grpc-Status"))
+	b, _ := ioutil.ReadAll(res.Body)
+	assert.Equal(t, "body", string(b))
+	assert.Equal(t, "0", res.Trailer.Get("Grpc-Status"))
+	assert.Equal(t, "OK", res.Trailer.Get("Grpc-Message"))
+}
+
+func TestContextHijack(t *testing.T) {
+	e := NewServeMux()
+	e.Use(Gzip())
+	e.GET("/ws", func(c Context) error {
+		conn, rw, err := c.Hijack()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\nhello")
+		return rw.Flush()
+	})
+	s := httptest.NewServer(e)
+	defer s.Close()
+
+	conn, err := net.Dial("tcp", s.Listener.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: localhost\r\nAccept-Encoding: gzip\r\n\r\n"))
+	b, _ := ioutil.ReadAll(conn)
+	assert.Equal(t, "HTTP/1.1 101 Switching Protocols\r\n\r\nhello", string(b))
+}
+
+func TestContextParams(t *testing.T) {
+	e := NewServeMux()
+	var params map[string]string
+	e.GET("/users/:uid/files/:fid", func(c Context) error {
+		params = c.Params()
+		return nil
+	})
+	request(http.MethodGet, "/users/101/files/501", e)
+	assert.Equal(t, map[string]string{"uid": "101", "fid": "501"}, params)
+
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	assert.Empty(t, c.Params())
+}
+
 func TestContextFormValue(t *testing.T) {
 	f := make(url.Values)
 	f.Set("name", "Jon Snow")
@@ -331,6 +654,510 @@ func TestContextMultipartForm(t *testing.T) {
 	}
 }
 
+func TestContextMultipartFormWithLimit(t *testing.T) {
+	e := NewServeMux()
+	e.MultipartMemoryLimit = 10
+	newRequest := func() *http.Request {
+		buf := new(bytes.Buffer)
+		mw := multipart.NewWriter(buf)
+		w, _ := mw.CreateFormFile("file", "walle.txt")
+		w.Write(bytes.Repeat([]byte("x"), 1024))
+		mw.Close()
+		req := httptest.NewRequest(http.MethodPost, "/", buf)
+		req.Header.Set(HeaderContentType, mw.FormDataContentType())
+		return req
+	}
+
+	// Files exceeding the limit are spilled to disk and removed afterwards
+	var tmp string
+	e.POST("/", func(c Context) error {
+		form, err := c.MultipartForm()
+		if err != nil {
+			return err
+		}
+		f, err := form.File["file"][0].Open()
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if osf, ok := f.(*os.File); ok {
+			tmp = osf.Name()
+		}
+		return c.NoContent(http.StatusOK)
+	})
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, newRequest())
+	assert.Equal(t, http.StatusOK, rec.Code)
+	if assert.NotEmpty(t, tmp) {
+		_, err := os.Stat(tmp)
+		assert.True(t, os.IsNotExist(err))
+	}
+
+	// Within the limit
+	c := e.NewContext(newRequest(), httptest.NewRecorder())
+	form, err := c.MultipartFormWithLimit(1 << 20)
+	if assert.NoError(t, err) {
+		f, _ := form.File["file"][0].Open()
+		_, ok := f.(*os.File)
+		assert.False(t, ok)
+	}
+}
+
+func TestContextMultipartReader(t *testing.T) {
+	e := NewServeMux()
+	newRequest := func() *http.Request {
+		buf := new(bytes.Buffer)
+		mw := multipart.NewWriter(buf)
+		mw.WriteField("name", "Jon Snow")
+		w, _ := mw.CreateFormFile("file", "walle.txt")
+		w.Write([]byte("walle"))
+		mw.Close()
+		req := httptest.NewRequest(http.MethodPost, "/", buf)
+		req.Header.Set(HeaderContentType, mw.FormDataContentType())
+		return req
+	}
+
+	c := e.NewContext(newRequest(), httptest.NewRecorder())
+	r, err := c.MultipartReader()
+	if assert.NoError(t, err) {
+		p, err := r.NextPart()
+		if assert.NoError(t, err) {
+			assert.Equal(t, "name", p.FormName())
+		}
+		p, err = r.NextPart()
+		if assert.NoError(t, err) {
+			assert.Equal(t, "walle.txt", p.FileName())
+			b, _ := ioutil.ReadAll(p)
+			assert.Equal(t, "walle", string(b))
+		}
+		_, err = r.NextPart()
+		assert.Equal(t, io.EOF, err)
+	}
+	_, err = c.MultipartForm()
+	assert.Error(t, err)
+
+	// Mutually exclusive with MultipartForm
+	c = e.NewContext(newRequest(), httptest.NewRecorder())
+	_, err = c.MultipartForm()
+	assert.NoError(t, err)
+	_, err = c.MultipartReader()
+	assert.Error(t, err)
+}
+
+func TestContextFlash(t *testing.T) {
+	e := NewServeMux()
+	e.FlashSecret = []byte("secret")
+	e.POST("/users", func(c Context) error {
+		if err := c.SetFlash("success", "User created"); err != nil {
+			return err
+		}
+		return c.Redirect(http.StatusSeeOther, "/users")
+	})
+	e.GET("/users", func(c Context) error {
+		flashes, err := c.Flashes()
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, flashes)
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users", nil))
+	cookies := rec.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+	assert.Equal(t, "flash", cookies[0].Name)
+
+	// Read once and cleared
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, `{"success":"User created"}`, rec.Body.String())
+	cookies = rec.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, -1, cookies[0].MaxAge)
+	}
+
+	// Without flashes
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Equal(t, "null", rec.Body.String())
+	assert.Empty(t, rec.Result().Cookies())
+
+	// Tampered
+	req = httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.AddCookie(&http.Cookie{Name: "flash", Value: "eyJ4IjoieSJ9.invalid"})
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "null", rec.Body.String())
+
+	c := NewServeMux().NewContext(req, httptest.NewRecorder())
+	assert.Equal(t, ErrFlashSecretNotSet, c.SetFlash("success", "User created"))
+	_, err := c.Flashes()
+	assert.Equal(t, ErrFlashSecretNotSet, err)
+}
+
+func TestContextAttr(t *testing.T) {
+	e := NewServeMux()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder()).(*context)
+	a := NewAttrKey("user")
+	b := NewAttrKey("user")
+	assert.Equal(t, "user", a.String())
+	assert.Nil(t, c.Attr(a))
+
+	c.SetAttr(a, "jon")
+	c.SetAttr(b, 1)
+	c.Set("user", true)
+	assert.Equal(t, "jon", c.Attr(a))
+	assert.Equal(t, 1, c.Attr(b))
+	assert.Equal(t, true, c.Get("user"))
+
+	c.reset(c.request, httptest.NewRecorder())
+	assert.Nil(t, c.Attr(a))
+}
+
+func TestContextBodyReader(t *testing.T) {
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":1,"name":"Jon Snow"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	for i := 0; i < 2; i++ {
+		b, err := ioutil.ReadAll(c.BodyReader())
+		if assert.NoError(t, err) {
+			assert.Equal(t, `{"id":1,"name":"Jon Snow"}`, string(b))
+		}
+	}
+
+	// The request body can still be bound
+	u := new(user)
+	if assert.NoError(t, c.Bind(u)) {
+		assert.Equal(t, "Jon Snow", u.Name)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", errReader{errors.New("read failed")})
+	c = e.NewContext(req, httptest.NewRecorder())
+	_, err := ioutil.ReadAll(c.BodyReader())
+	assert.EqualError(t, err, "read failed")
+}
+
+func TestContextBodyBytes(t *testing.T) {
+	e := NewServeMux()
+	e.BodyLimit = 32
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(userJSON))
+	c := e.NewContext(req, httptest.NewRecorder())
+	for i := 0; i < 2; i++ {
+		b, err := c.BodyBytes()
+		if assert.NoError(t, err) {
+			assert.Equal(t, userJSON, string(b))
+		}
+	}
+	b, _ := ioutil.ReadAll(req.Body)
+	assert.Equal(t, userJSON, string(b))
+
+	// Too large
+	large := strings.Repeat("x", 33)
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(large))
+	c = e.NewContext(req, httptest.NewRecorder())
+	_, err := c.BodyBytes()
+	assert.Equal(t, ErrStatusRequestEntityTooLarge, err)
+
+	// Of unknown length, the body remains readable
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(large))
+	req.ContentLength = -1
+	c = e.NewContext(req, httptest.NewRecorder())
+	_, err = c.BodyBytes()
+	assert.Equal(t, ErrStatusRequestEntityTooLarge, err)
+	b, _ = ioutil.ReadAll(c.Request().Body)
+	assert.Equal(t, large, string(b))
+}
+
+func TestContextBindFiles(t *testing.T) {
+	e := NewServeMux()
+	buf := new(bytes.Buffer)
+	mw := multipart.NewWriter(buf)
+	mw.WriteField("name", "Jon Snow")
+	for _, f := range []struct{ field, name string }{
+		{"avatar", "jon.png"},
+		{"documents", "a.pdf"},
+		{"documents", "b.pdf"},
+	} {
+		w, err := mw.CreateFormFile(f.field, f.name)
+		if assert.NoError(t, err) {
+			w.Write([]byte(f.name))
+		}
+	}
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/", buf)
+	req.Header.Set(HeaderContentType, mw.FormDataContentType())
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	files, err := c.BindFiles()
+	if assert.NoError(t, err) {
+		assert.Len(t, files, 2)
+		if assert.Len(t, files["avatar"], 1) {
+			assert.Equal(t, "jon.png", files["avatar"][0].Filename)
+		}
+		if assert.Len(t, files["documents"], 2) {
+			assert.Equal(t, "a.pdf", files["documents"][0].Filename)
+			assert.Equal(t, "b.pdf", files["documents"][1].Filename)
+		}
+	}
+
+	// Not a multipart form
+	c = e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder())
+	_, err = c.BindFiles()
+	assert.Error(t, err)
+}
+
+func TestContextFile(t *testing.T) {
+	e := NewServeMux()
+
+	assert := assert.New(t)
+
+	// Range
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderRange, "bytes=0-9")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(c.File("testdata/images/walle.png")) {
+		assert.Equal(http.StatusPartialContent, rec.Code)
+		assert.Equal("bytes 0-9/219885", rec.Header().Get(HeaderContentRange))
+		assert.Equal(10, rec.Body.Len())
+	}
+
+	// If-Modified-Since
+	rec = httptest.NewRecorder()
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	if assert.NoError(c.File("testdata/images/walle.png")) {
+		assert.Equal(http.StatusOK, rec.Code)
+		assert.NotEmpty(rec.Header().Get(HeaderLastModified))
+		assert.NotEmpty(rec.Header().Get(HeaderETag))
+	}
+	lastModified := rec.Header().Get(HeaderLastModified)
+	etag := rec.Header().Get(HeaderETag)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderIfModifiedSince, lastModified)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	if assert.NoError(c.File("testdata/images/walle.png")) {
+		assert.Equal(http.StatusNotModified, rec.Code)
+		assert.Equal(0, rec.Body.Len())
+	}
+
+	// If-None-Match
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderIfNoneMatch, etag)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	if assert.NoError(c.File("testdata/images/walle.png")) {
+		assert.Equal(http.StatusNotModified, rec.Code)
+	}
+
+	// Not found
+	rec = httptest.NewRecorder()
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	assert.Equal(ErrNotFound, c.File("testdata/images/missing.png"))
+}
+
+func TestContextJSONStream(t *testing.T) {
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, c.JSONStream(http.StatusOK, user{1, "Jon Snow"})) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, MIMEApplicationJSONCharsetUTF8, rec.Header().Get(HeaderContentType))
+		assert.Equal(t, userJSON+"\n", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	if assert.NoError(t, c.JSONStreamPretty(http.StatusOK, user{1, "Jon Snow"}, "  ")) {
+		assert.Equal(t, userJSONPretty+"\n", rec.Body.String())
+	}
+
+	// Status is committed before encoding fails
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	assert.Error(t, c.JSONStream(http.StatusCreated, make(chan bool)))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.True(t, c.Response().Committed)
+}
+
+func TestContextJSONArrayStream(t *testing.T) {
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	w, err := c.JSONArrayStream(http.StatusOK)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for i := 1; i <= 150; i++ {
+		assert.NoError(t, w.Write(user{i, "Jon Snow"}))
+	}
+	assert.True(t, rec.Flushed)
+	assert.NoError(t, w.Close())
+	assert.NoError(t, w.Close())
+	assert.Error(t, w.Write(user{}))
+
+	assert.Equal(t, MIMEApplicationJSONCharsetUTF8, rec.Header().Get(HeaderContentType))
+	var users []user
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &users)) {
+		assert.Len(t, users, 150)
+		assert.Equal(t, user{150, "Jon Snow"}, users[149])
+	}
+
+	// Empty array
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	w, _ = c.JSONArrayStream(http.StatusOK)
+	w.Close()
+	assert.Equal(t, "[]", rec.Body.String())
+}
+
+func TestContextMultipart(t *testing.T) {
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	w, err := c.Multipart(http.StatusOK)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, body := range []string{`{"id":1}`, `{"id":2}`} {
+		p, err := w.CreatePart(textproto.MIMEHeader{HeaderContentType: {MIMEApplicationJSON}})
+		if assert.NoError(t, err) {
+			io.WriteString(p, body)
+		}
+	}
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, MIMEMultipartMixed+"; boundary="+w.Boundary(), rec.Header().Get(HeaderContentType))
+	b := w.Boundary()
+	assert.Equal(t, "--"+b+"\r\nContent-Type: application/json\r\n\r\n{\"id\":1}\r\n"+
+		"--"+b+"\r\nContent-Type: application/json\r\n\r\n{\"id\":2}\r\n"+
+		"--"+b+"--\r\n", rec.Body.String())
+
+	// Byteranges
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.Response().Header().Set(HeaderContentType, MIMEMultipartByteranges)
+	w, _ = c.Multipart(http.StatusPartialContent)
+	w.Close()
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Equal(t, MIMEMultipartByteranges+"; boundary="+w.Boundary(), rec.Header().Get(HeaderContentType))
+}
+
+func TestContextXML(t *testing.T) {
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	err := c.XML(http.StatusOK, user{1, "Jon Snow"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, MIMEApplicationXMLCharsetUTF8, rec.Header().Get(HeaderContentType))
+		assert.Equal(t, xml.Header+userXML, rec.Body.String())
+	}
+}
+
+func TestContextNegotiate(t *testing.T) {
+	e := NewServeMux()
+	e.Renderer = &Template{
+		templates: template.Must(template.New("user").Parse("<p>{{.Name}}</p>")),
+	}
+	tests := []struct {
+		accept   string
+		template []string
+		ctype    string
+		err      error
+	}{
+		{"", nil, MIMEApplicationJSONCharsetUTF8, nil},
+		{"application/json", nil, MIMEApplicationJSONCharsetUTF8, nil},
+		{"application/xml", nil, MIMEApplicationXMLCharsetUTF8, nil},
+		{"text/xml", nil, MIMEApplicationXMLCharsetUTF8, nil},
+		{"application/json;q=0.5, application/xml", nil, MIMEApplicationXMLCharsetUTF8, nil},
+		{"application/xml;q=0.2, */*;q=0.8", nil, MIMEApplicationJSONCharsetUTF8, nil},
+		{"application/*", nil, MIMEApplicationJSONCharsetUTF8, nil},
+		{"text/html, application/xml;q=0.9", nil, MIMEApplicationXMLCharsetUTF8, nil},
+		{"text/html, application/xml;q=0.9", []string{"user"}, MIMETextHTMLCharsetUTF8, nil},
+		{"image/png, */*;q=0.1", nil, MIMEApplicationJSONCharsetUTF8, nil},
+		{"image/png", nil, "", ErrNotAcceptable},
+		{"application/json;q=0", nil, "", ErrNotAcceptable},
+		{"*/*;q=0", nil, "", ErrNotAcceptable},
+		{"application/json;q=0, application/xml;q=0, text/xml;q=0, */*", nil, "", ErrNotAcceptable},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(HeaderAccept, tt.accept)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		err := c.Negotiate(http.StatusOK, user{1, "Jon Snow"}, tt.template...)
+		assert.Equal(t, tt.err, err, tt.accept)
+		if tt.err == nil {
+			assert.Equal(t, tt.ctype, rec.Header().Get(HeaderContentType), tt.accept)
+		}
+	}
+}
+
+func TestContextNoContentHeaders(t *testing.T) {
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Response().Header().Set(HeaderContentType, MIMEApplicationJSON)
+	c.Response().Header().Set(HeaderContentLength, "42")
+	c.Response().Header().Set(HeaderContentEncoding, "gzip")
+	assert.NoError(t, c.NoContent(http.StatusNoContent))
+	assert.Empty(t, rec.Header().Get(HeaderContentType))
+	assert.Empty(t, rec.Header().Get(HeaderContentLength))
+	assert.Equal(t, "gzip", rec.Header().Get(HeaderContentEncoding))
+
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.Response().Header().Set(HeaderContentType, MIMEApplicationJSON)
+	c.Response().Header().Set(HeaderContentEncoding, "gzip")
+	c.Response().Header().Set(HeaderETag, `"v1"`)
+	assert.NoError(t, c.NoContent(http.StatusNotModified))
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Header().Get(HeaderContentType))
+	assert.Empty(t, rec.Header().Get(HeaderContentEncoding))
+	assert.Equal(t, `"v1"`, rec.Header().Get(HeaderETag))
+}
+
+func TestContextNoContentVariants(t *testing.T) {
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, c.Created("/users/1")) {
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "/users/1", rec.Header().Get(HeaderLocation))
+		assert.Equal(t, 0, rec.Body.Len())
+	}
+
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	if assert.NoError(t, c.Accepted()) {
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	if assert.NoError(t, c.NoContent204()) {
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	}
+}
+
 func TestContextRedirect(t *testing.T) {
 	e := NewServeMux()
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -342,6 +1169,77 @@ func TestContextRedirect(t *testing.T) {
 	assert.Error(t, c.Redirect(310, "http://dostack.github.io/mux"))
 }
 
+func TestContextRedirectTo(t *testing.T) {
+	e := NewServeMux()
+	e.GET("/users/:id", func(c Context) error { return nil }).Name = "user"
+	e.GET("/export?type=pdf", func(c Context) error { return nil }).Name = "export"
+	req := httptest.NewRequest(http.MethodGet, "/old?page=2", nil)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	assert.NoError(t, c.RedirectTo(http.StatusFound, "user", 1))
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/users/1", rec.Header().Get(HeaderLocation))
+
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	assert.NoError(t, c.RedirectTo(http.StatusMovedPermanently, "user", PreserveQuery, "jon snow"))
+	assert.Equal(t, "/users/jon%20snow?page=2", rec.Header().Get(HeaderLocation))
+
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	assert.NoError(t, c.RedirectTo(http.StatusFound, "export", PreserveQuery))
+	assert.Equal(t, "/export?type=pdf&page=2", rec.Header().Get(HeaderLocation))
+
+	c = e.NewContext(req, httptest.NewRecorder())
+	assert.Equal(t, ErrInvalidRedirectCode, c.RedirectTo(http.StatusOK, "user", 1))
+	assert.EqualError(t, c.RedirectTo(http.StatusFound, "missing"), `route: no route named "missing"`)
+}
+
+func TestContextForward(t *testing.T) {
+	e := NewServeMux()
+	e.GET("/a", func(c Context) error {
+		return c.Forward(http.MethodGet, "/b/1?q=x")
+	})
+	e.GET("/b/:id", func(c Context) error {
+		return c.String(http.StatusOK, "b "+c.Param("id")+" "+c.QueryParam("q"))
+	})
+	e.GET("/loop", func(c Context) error {
+		return c.Forward(http.MethodGet, "/loop")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/a", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "b 1 x", rec.Body.String())
+
+	req := httptest.NewRequest(http.MethodGet, "/loop", nil)
+	c := e.NewContext(req, httptest.NewRecorder())
+	e.router.find(http.MethodGet, "/loop", c)
+	assert.Equal(t, ErrTooManyForwards, c.Handler()(c))
+
+	// Only the middleware of the route forwarded to is run
+	e = NewServeMux()
+	trace := ""
+	tracer := func(name string) MiddlewareFunc {
+		return func(c Context, next HandlerFunc) error {
+			trace += name + " "
+			return next(c)
+		}
+	}
+	e.Use(tracer("mux"))
+	e.GET("/a", func(c Context) error {
+		return c.Forward(http.MethodGet, "/b")
+	}, tracer("a"))
+	e.GET("/b", func(c Context) error {
+		return c.String(http.StatusOK, "b")
+	}, tracer("b"))
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/a", nil))
+	assert.Equal(t, "b", rec.Body.String())
+	assert.Equal(t, "mux a b ", trace)
+}
+
 func TestContextStore(t *testing.T) {
 	var c Context
 	c = new(context)
@@ -362,3 +1260,25 @@ func TestContextHandler(t *testing.T) {
 	c.Handler()(c)
 	assert.Equal(t, "handler", b.String())
 }
+
+func TestContextParamWildcard(t *testing.T) {
+	mux := NewServeMux()
+	var got string
+	mux.GET("/files/*", func(c Context) error {
+		got = c.ParamWildcard()
+		return c.NoContent(http.StatusOK)
+	})
+
+	for path, want := range map[string]string{
+		"/files/a":              "a",
+		"/files/a/b/c":          "a/b/c",
+		"/files/a%2Fb/c":        "a/b/c",
+		"/files/a%20b/c%25.txt": "a b/c%.txt",
+		"/files/":               "",
+	} {
+		got = ""
+		code, _ := request(http.MethodGet, path, mux)
+		assert.Equal(t, http.StatusOK, code, path)
+		assert.Equal(t, want, got, path)
+	}
+}
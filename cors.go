@@ -0,0 +1,168 @@
+package route
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CORSConfig defines the config for CORS middleware.
+type CORSConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper Skipper
+
+	// AllowOrigins is the list of origins which may access the resource, or
+	// "*" for any origin. Default ["*"].
+	AllowOrigins []string
+
+	// AllowMethods is the list of methods allowed when accessing the resource,
+	// sent in response to preflight requests. Default DefaultCORSConfig's.
+	AllowMethods []string
+
+	// AllowHeaders is the list of request headers which can be used when
+	// making the actual request. Default the requested headers.
+	AllowHeaders []string
+
+	// AllowCredentials indicates whether the response can be exposed when
+	// the request includes credentials.
+	AllowCredentials bool
+
+	// ExposeHeaders is the list of response headers clients are allowed to
+	// access.
+	ExposeHeaders []string
+
+	// MaxAge is how long in seconds the result of a preflight request can be
+	// cached.
+	MaxAge int
+}
+
+// DefaultCORSConfig is the default CORS middleware config.
+var DefaultCORSConfig = CORSConfig{
+	Skipper:      DefaultSkipper,
+	AllowOrigins: []string{"*"},
+	AllowMethods: []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete},
+}
+
+// WithCORS overrides the config of the CORS middleware for the route, e.g. to
+// allow other origins than the global policy. Defaults are applied as for
+// `CORSWithConfig()`, the Skipper is ignored.
+func (r *Route) WithCORS(config CORSConfig) *Route {
+	config = config.withDefaults()
+	r.cors = &config
+	return r
+}
+
+// CORS returns a Cross-Origin Resource Sharing (CORS) middleware which allows
+// requests from any origin.
+// See: https://developer.mozilla.org/en/docs/Web/HTTP/Access_control_CORS
+func CORS() MiddlewareFunc {
+	return CORSWithConfig(DefaultCORSConfig)
+}
+
+// CORSWithConfig returns a CORS middleware with config. Routes can override
+// the config using `Route#WithCORS()`.
+//
+// Only OPTIONS requests with an Access-Control-Request-Method header are
+// handled as preflight requests and answered by the middleware. Other OPTIONS
+// requests reach the handler registered for them like any other request.
+// See: `CORS()`.
+func CORSWithConfig(config CORSConfig) MiddlewareFunc {
+	config = config.withDefaults()
+
+	return func(c Context, next HandlerFunc) error {
+		if config.Skipper(c) {
+			return next(c)
+		}
+
+		req := c.Request()
+		preflight := isPreflight(req)
+		cfg := &config
+		if route := corsRoute(c, preflight); route != nil && route.cors != nil {
+			cfg = route.cors
+		}
+
+		header := c.Response().Header()
+		origin := req.Header.Get(HeaderOrigin)
+		header.Add(HeaderVary, HeaderOrigin)
+		allowOrigin := cfg.allowOrigin(origin)
+
+		if !preflight {
+			if allowOrigin != "" {
+				header.Set(HeaderAccessControlAllowOrigin, allowOrigin)
+				if cfg.AllowCredentials {
+					header.Set(HeaderAccessControlAllowCredentials, "true")
+				}
+				if len(cfg.ExposeHeaders) > 0 {
+					header.Set(HeaderAccessControlExposeHeaders, strings.Join(cfg.ExposeHeaders, ","))
+				}
+			}
+			return next(c)
+		}
+
+		header.Add(HeaderVary, HeaderAccessControlRequestMethod)
+		header.Add(HeaderVary, HeaderAccessControlRequestHeaders)
+		if allowOrigin == "" {
+			return c.NoContent(http.StatusNoContent)
+		}
+		header.Set(HeaderAccessControlAllowOrigin, allowOrigin)
+		header.Set(HeaderAccessControlAllowMethods, strings.Join(cfg.AllowMethods, ","))
+		if cfg.AllowCredentials {
+			header.Set(HeaderAccessControlAllowCredentials, "true")
+		}
+		if len(cfg.AllowHeaders) > 0 {
+			header.Set(HeaderAccessControlAllowHeaders, strings.Join(cfg.AllowHeaders, ","))
+		} else if h := req.Header.Get(HeaderAccessControlRequestHeaders); h != "" {
+			header.Set(HeaderAccessControlAllowHeaders, h)
+		}
+		if cfg.MaxAge > 0 {
+			header.Set(HeaderAccessControlMaxAge, strconv.Itoa(cfg.MaxAge))
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+// withDefaults returns config with the defaults of DefaultCORSConfig applied.
+func (config CORSConfig) withDefaults() CORSConfig {
+	if config.Skipper == nil {
+		config.Skipper = DefaultCORSConfig.Skipper
+	}
+	if len(config.AllowOrigins) == 0 {
+		config.AllowOrigins = DefaultCORSConfig.AllowOrigins
+	}
+	if len(config.AllowMethods) == 0 {
+		config.AllowMethods = DefaultCORSConfig.AllowMethods
+	}
+	return config
+}
+
+// allowOrigin returns the value of the Access-Control-Allow-Origin header for
+// origin, or an empty string if origin isn't allowed.
+func (config *CORSConfig) allowOrigin(origin string) string {
+	for _, o := range config.AllowOrigins {
+		if o == "*" {
+			if config.AllowCredentials && origin != "" {
+				return origin
+			}
+			return "*"
+		}
+		if o == origin {
+			return origin
+		}
+	}
+	return ""
+}
+
+// corsRoute returns the route the request is for. Preflight requests are
+// matched against the route of the requested method.
+func corsRoute(c Context, preflight bool) *Route {
+	if !preflight {
+		return c.RouteInfo()
+	}
+	method := c.Request().Header.Get(HeaderAccessControlRequestMethod)
+	return c.Mux().findRoute(method, c.Request())
+}
+
+// isPreflight reports whether r is a CORS preflight request.
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions && r.Header.Get(HeaderAccessControlRequestMethod) != ""
+}
@@ -0,0 +1,115 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func corsRequest(e *Mux, method, path, origin string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set(HeaderOrigin, origin)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORS(t *testing.T) {
+	e := NewServeMux()
+	e.Use(CORS())
+	e.GET("/", func(c Context) error { return c.String(http.StatusOK, "OK") })
+
+	rec := corsRequest(e, http.MethodGet, "/", "http://example.com", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "*", rec.Header().Get(HeaderAccessControlAllowOrigin))
+
+	// Preflight
+	rec = corsRequest(e, http.MethodOptions, "/", "http://example.com", http.Header{
+		HeaderAccessControlRequestMethod:  {http.MethodGet},
+		HeaderAccessControlRequestHeaders: {"X-Token"},
+	})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "*", rec.Header().Get(HeaderAccessControlAllowOrigin))
+	assert.Equal(t, "GET,HEAD,PUT,PATCH,POST,DELETE", rec.Header().Get(HeaderAccessControlAllowMethods))
+	assert.Equal(t, "X-Token", rec.Header().Get(HeaderAccessControlAllowHeaders))
+}
+
+func TestCORSWithConfig(t *testing.T) {
+	e := NewServeMux()
+	e.Use(CORSWithConfig(CORSConfig{
+		AllowOrigins:     []string{"http://example.com"},
+		AllowCredentials: true,
+		ExposeHeaders:    []string{"X-Total"},
+		MaxAge:           3600,
+	}))
+	e.GET("/", func(c Context) error { return c.String(http.StatusOK, "OK") })
+
+	rec := corsRequest(e, http.MethodGet, "/", "http://example.com", nil)
+	assert.Equal(t, "http://example.com", rec.Header().Get(HeaderAccessControlAllowOrigin))
+	assert.Equal(t, "true", rec.Header().Get(HeaderAccessControlAllowCredentials))
+	assert.Equal(t, "X-Total", rec.Header().Get(HeaderAccessControlExposeHeaders))
+
+	rec = corsRequest(e, http.MethodGet, "/", "http://evil.com", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get(HeaderAccessControlAllowOrigin))
+
+	rec = corsRequest(e, http.MethodOptions, "/", "http://example.com", http.Header{
+		HeaderAccessControlRequestMethod: {http.MethodGet},
+	})
+	assert.Equal(t, "3600", rec.Header().Get(HeaderAccessControlMaxAge))
+}
+
+func TestCORSRouteOverride(t *testing.T) {
+	e := NewServeMux()
+	e.Use(CORSWithConfig(CORSConfig{AllowOrigins: []string{"http://example.com"}}))
+	h := func(c Context) error { return c.String(http.StatusOK, "OK") }
+	e.GET("/users", h)
+	e.POST("/widget", h).WithCORS(CORSConfig{
+		AllowOrigins: []string{"http://partner.com"},
+		AllowMethods: []string{http.MethodPost},
+	})
+
+	// Rejected by the global policy
+	rec := corsRequest(e, http.MethodGet, "/users", "http://partner.com", nil)
+	assert.Empty(t, rec.Header().Get(HeaderAccessControlAllowOrigin))
+
+	// Allowed by the route
+	rec = corsRequest(e, http.MethodPost, "/widget", "http://partner.com", nil)
+	assert.Equal(t, "http://partner.com", rec.Header().Get(HeaderAccessControlAllowOrigin))
+	rec = corsRequest(e, http.MethodPost, "/widget", "http://example.com", nil)
+	assert.Empty(t, rec.Header().Get(HeaderAccessControlAllowOrigin))
+
+	// Preflight requests use the route of the requested method
+	rec = corsRequest(e, http.MethodOptions, "/widget", "http://partner.com", http.Header{
+		HeaderAccessControlRequestMethod: {http.MethodPost},
+	})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "http://partner.com", rec.Header().Get(HeaderAccessControlAllowOrigin))
+	assert.Equal(t, "POST", rec.Header().Get(HeaderAccessControlAllowMethods))
+}
+
+func TestCORSOptions(t *testing.T) {
+	e := NewServeMux()
+	e.Use(CORS())
+	e.OPTIONS("/", func(c Context) error { return c.String(http.StatusOK, "options") })
+
+	// Preflight requests are handled by the middleware
+	rec := corsRequest(e, http.MethodOptions, "/", "http://example.com", http.Header{
+		HeaderAccessControlRequestMethod: {http.MethodGet},
+	})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "GET,HEAD,PUT,PATCH,POST,DELETE", rec.Header().Get(HeaderAccessControlAllowMethods))
+	assert.Empty(t, rec.Body.String())
+
+	// Other OPTIONS requests reach the route
+	rec = corsRequest(e, http.MethodOptions, "/", "http://example.com", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "options", rec.Body.String())
+	assert.Equal(t, "*", rec.Header().Get(HeaderAccessControlAllowOrigin))
+	assert.Empty(t, rec.Header().Get(HeaderAccessControlAllowMethods))
+}
@@ -0,0 +1,75 @@
+package route
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DataURI is a file embedded in a request as a data URI, e.g.
+// "data:image/png;base64,iVBORw0...", for JSON APIs which can't use multipart
+// forms. Plain base64 strings are accepted as well. It can be bound from JSON
+// and, as it implements BindUnmarshaler, from form and query params.
+//
+//	type Profile struct {
+//		Avatar route.DataURI `json:"avatar"`
+//	}
+type DataURI struct {
+	// Data is the decoded content.
+	Data []byte
+
+	// MediaType is the declared media type, e.g. "image/png". It is detected
+	// from Data if the data URI doesn't declare one.
+	MediaType string
+}
+
+// ErrInvalidDataURI is returned when binding a malformed data URI.
+var ErrInvalidDataURI = errors.New("invalid data URI")
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (d *DataURI) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return d.UnmarshalParam(s)
+}
+
+// UnmarshalParam implements the `BindUnmarshaler#UnmarshalParam` function.
+func (d *DataURI) UnmarshalParam(s string) (err error) {
+	if s == "" {
+		*d = DataURI{}
+		return nil
+	}
+	mediaType := ""
+	encoded := true
+	if strings.HasPrefix(s, "data:") {
+		i := strings.IndexByte(s, ',')
+		if i < 0 {
+			return ErrInvalidDataURI
+		}
+		mediaType, s = s[len("data:"):i], s[i+1:]
+		encoded = strings.HasSuffix(mediaType, ";base64")
+		mediaType = strings.TrimSuffix(mediaType, ";base64")
+	}
+
+	var data []byte
+	if encoded {
+		if data, err = base64.StdEncoding.DecodeString(s); err != nil {
+			return ErrInvalidDataURI
+		}
+	} else {
+		if s, err = url.PathUnescape(s); err != nil {
+			return ErrInvalidDataURI
+		}
+		data = []byte(s)
+	}
+	if mediaType == "" || strings.HasPrefix(mediaType, ";") {
+		mediaType = http.DetectContentType(data)
+	}
+	*d = DataURI{Data: data, MediaType: mediaType}
+	return nil
+}
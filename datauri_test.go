@@ -0,0 +1,64 @@
+package route
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var pngHeader = []byte("\x89PNG\x0D\x0A\x1A\x0A")
+
+func TestBindDataURI(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString(pngHeader)
+	body := `{"avatar":"data:image/png;base64,` + encoded + `","files":["` + encoded + `","data:,Hello%2C%20World"]}`
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	v := struct {
+		Avatar DataURI   `json:"avatar"`
+		Files  []DataURI `json:"files"`
+	}{}
+	if assert.NoError(t, c.Bind(&v)) {
+		assert.Equal(t, pngHeader, v.Avatar.Data)
+		assert.Equal(t, "image/png", v.Avatar.MediaType)
+		if assert.Len(t, v.Files, 2) {
+			// Detected
+			assert.Equal(t, pngHeader, v.Files[0].Data)
+			assert.Equal(t, "image/png", v.Files[0].MediaType)
+			assert.Equal(t, "Hello, World", string(v.Files[1].Data))
+			assert.Equal(t, "text/plain; charset=utf-8", v.Files[1].MediaType)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"avatar":"data:image/png;base64,!"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	c = e.NewContext(req, httptest.NewRecorder())
+	err := c.Bind(&v)
+	if assert.IsType(t, new(HTTPError), err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*HTTPError).Code)
+	}
+}
+
+func TestBindDataURIForm(t *testing.T) {
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodGet, "/?avatar="+url.QueryEscape("data:image/png;base64,"+base64.StdEncoding.EncodeToString(pngHeader)), nil)
+	c := e.NewContext(req, httptest.NewRecorder())
+	v := struct {
+		Avatar DataURI `query:"avatar"`
+	}{}
+	if assert.NoError(t, c.Bind(&v)) {
+		assert.Equal(t, "image/png", v.Avatar.MediaType)
+	}
+
+	d := DataURI{}
+	assert.Equal(t, ErrInvalidDataURI, d.UnmarshalParam("data:image/png;base64"))
+	assert.NoError(t, d.UnmarshalParam(""))
+	assert.Equal(t, DataURI{}, d)
+}
@@ -1,6 +1,7 @@
 package route
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 )
@@ -24,3 +25,18 @@ func (he *HTTPError) SetInternal(err error) *HTTPError {
 	he.Internal = err
 	return he
 }
+
+// Unwrap returns the internal error, so that errors.Is and errors.As can
+// inspect the cause of he.
+func (he *HTTPError) Unwrap() error {
+	return he.Internal
+}
+
+// AsHTTPError finds the first HTTPError in the chain of err.
+func AsHTTPError(err error) (*HTTPError, bool) {
+	var he *HTTPError
+	if errors.As(err, &he) {
+		return he, true
+	}
+	return nil, false
+}
@@ -0,0 +1,150 @@
+package route
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"hash"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ETagConfig defines the config for ETag middleware.
+type ETagConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper Skipper
+
+	// Hash returns the hash the ETag is computed with. Default sha1.New.
+	Hash func() hash.Hash
+
+	// Weak generates weak ETags, e.g. W/"1a2b", which state that responses
+	// are semantically equivalent rather than byte for byte identical.
+	Weak bool
+}
+
+// DefaultETagConfig is the default ETag middleware config.
+var DefaultETagConfig = ETagConfig{
+	Skipper: DefaultSkipper,
+	Hash:    sha1.New,
+}
+
+// etagWriter buffers the response body to compute its ETag, until the handler
+// flushes the response.
+type etagWriter struct {
+	http.ResponseWriter
+	buf       bytes.Buffer
+	code      int
+	streaming bool
+}
+
+// ETag returns a middleware which sets the ETag header of successful responses
+// to GET requests to the hash of the body, and responds with 304 Not Modified
+// if the If-None-Match header of the request matches it. Responses which
+// already have an ETag keep it. The body is buffered to compute the hash, so
+// streamed responses, which are flushed by the handler, are sent unchanged.
+func ETag() MiddlewareFunc {
+	return ETagWithConfig(DefaultETagConfig)
+}
+
+// ETagWithConfig returns an ETag middleware with config.
+// See: `ETag()`.
+func ETagWithConfig(config ETagConfig) MiddlewareFunc {
+	if config.Skipper == nil {
+		config.Skipper = DefaultETagConfig.Skipper
+	}
+	if config.Hash == nil {
+		config.Hash = DefaultETagConfig.Hash
+	}
+
+	return func(c Context, next HandlerFunc) error {
+		if config.Skipper(c) || c.Request().Method != http.MethodGet {
+			return next(c)
+		}
+
+		res := c.Response()
+		rw := res.Writer
+		w := &etagWriter{ResponseWriter: rw, code: http.StatusOK}
+		res.Writer = w
+		defer func() {
+			res.Writer = rw
+		}()
+		err := next(c)
+		if !res.Committed || w.streaming {
+			return err
+		}
+
+		header := rw.Header()
+		if w.code == http.StatusOK {
+			etag := header.Get(HeaderETag)
+			if etag == "" {
+				h := config.Hash()
+				h.Write(w.buf.Bytes())
+				etag = `"` + hex.EncodeToString(h.Sum(nil)) + `"`
+				if config.Weak {
+					etag = "W/" + etag
+				}
+				header.Set(HeaderETag, etag)
+			}
+			if etagMatch(c.Request().Header.Get(HeaderIfNoneMatch), etag) {
+				removeBodyHeaders(header, http.StatusNotModified)
+				res.Status = http.StatusNotModified
+				res.Size = 0
+				rw.WriteHeader(http.StatusNotModified)
+				return err
+			}
+		}
+		rw.WriteHeader(w.code)
+		rw.Write(w.buf.Bytes())
+		return err
+	}
+}
+
+// etagMatch reports whether the If-None-Match header value matches etag
+// using the weak comparison.
+func etagMatch(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, t := range strings.Split(ifNoneMatch, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *etagWriter) WriteHeader(code int) {
+	w.code = code
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+// Flush sends the buffered response and switches to streaming.
+func (w *etagWriter) Flush() {
+	if !w.streaming {
+		w.streaming = true
+		w.ResponseWriter.WriteHeader(w.code)
+		w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *etagWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		w.streaming = true
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
@@ -0,0 +1,87 @@
+package route
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func etagRequest(e *Mux, method, path, ifNoneMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set(HeaderIfNoneMatch, ifNoneMatch)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestETag(t *testing.T) {
+	e := NewServeMux()
+	e.Use(ETag())
+	h := func(c Context) error { return c.JSON(http.StatusOK, user{1, "Jon Snow"}) }
+	e.GET("/", h)
+	e.POST("/", h)
+	e.GET("/versioned", func(c Context) error {
+		c.Response().Header().Set(HeaderETag, `"v1"`)
+		return c.String(http.StatusOK, "OK")
+	})
+	e.GET("/stream", func(c Context) error {
+		c.Response().Write([]byte("chunk"))
+		c.Response().Flush()
+		return nil
+	})
+	e.GET("/error", func(c Context) error { return ErrForbidden })
+
+	rec := etagRequest(e, http.MethodGet, "/", "")
+	etag := rec.Header().Get(HeaderETag)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, userJSON, rec.Body.String())
+	assert.Regexp(t, `^"[0-9a-f]{40}"$`, etag)
+
+	rec = etagRequest(e, http.MethodGet, "/", `"other", `+etag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, etag, rec.Header().Get(HeaderETag))
+	assert.Empty(t, rec.Header().Get(HeaderContentType))
+	assert.Empty(t, rec.Body.String())
+
+	rec = etagRequest(e, http.MethodGet, "/", `"other"`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, userJSON, rec.Body.String())
+
+	// Only GET requests
+	rec = etagRequest(e, http.MethodPost, "/", etag)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get(HeaderETag))
+
+	// ETags set by the handler are kept
+	rec = etagRequest(e, http.MethodGet, "/versioned", `W/"v1"`)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, `"v1"`, rec.Header().Get(HeaderETag))
+
+	// Streamed responses are sent unchanged
+	rec = etagRequest(e, http.MethodGet, "/stream", "*")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "chunk", rec.Body.String())
+	assert.Empty(t, rec.Header().Get(HeaderETag))
+
+	rec = etagRequest(e, http.MethodGet, "/error", "*")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Empty(t, rec.Header().Get(HeaderETag))
+}
+
+func TestETagWithConfig(t *testing.T) {
+	e := NewServeMux()
+	e.Use(ETagWithConfig(ETagConfig{Hash: sha256.New, Weak: true}))
+	e.GET("/", func(c Context) error { return c.String(http.StatusOK, "OK") })
+
+	rec := etagRequest(e, http.MethodGet, "/", "")
+	etag := rec.Header().Get(HeaderETag)
+	assert.Regexp(t, `^W/"[0-9a-f]{64}"$`, etag)
+
+	rec = etagRequest(e, http.MethodGet, "/", etag[2:])
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+}
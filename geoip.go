@@ -0,0 +1,71 @@
+package route
+
+type (
+	// GeoInfo is the location of a client IP address.
+	GeoInfo struct {
+		Country string
+		Region  string
+		City    string
+	}
+
+	// GeoLookup resolves IP addresses into their location, e.g. using a GeoIP
+	// database.
+	GeoLookup interface {
+		Lookup(ip string) (GeoInfo, error)
+	}
+
+	// GeoLookupFunc is an adapter to use a function as GeoLookup.
+	GeoLookupFunc func(ip string) (GeoInfo, error)
+
+	// GeoIPConfig defines the config for GeoIP middleware.
+	GeoIPConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Lookup resolves the client IP. Required.
+		Lookup GeoLookup
+	}
+)
+
+// GeoInfoKey is the context key the GeoIP middleware stores the GeoInfo of the
+// client under.
+const GeoInfoKey = "geo"
+
+// Lookup implements the `GeoLookup#Lookup` function.
+func (f GeoLookupFunc) Lookup(ip string) (GeoInfo, error) {
+	return f(ip)
+}
+
+// GeoIP returns a middleware which resolves `Context#RealIP()` with lookup and
+// stores the result on the context for downstream handlers, see `Geo()`.
+// Requests are processed without GeoInfo if the lookup fails.
+func GeoIP(lookup func(ip string) (GeoInfo, error)) MiddlewareFunc {
+	return GeoIPWithConfig(GeoIPConfig{Lookup: GeoLookupFunc(lookup)})
+}
+
+// GeoIPWithConfig returns a GeoIP middleware with config.
+// See: `GeoIP()`.
+func GeoIPWithConfig(config GeoIPConfig) MiddlewareFunc {
+	if config.Lookup == nil {
+		panic("route: geoip middleware requires a lookup")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultSkipper
+	}
+
+	return func(c Context, next HandlerFunc) error {
+		if config.Skipper(c) {
+			return next(c)
+		}
+		if info, err := config.Lookup.Lookup(c.RealIP()); err == nil {
+			c.Set(GeoInfoKey, info)
+		}
+		return next(c)
+	}
+}
+
+// Geo returns the GeoInfo stored on c by the GeoIP middleware.
+func Geo(c Context) (GeoInfo, bool) {
+	info, ok := c.Get(GeoInfoKey).(GeoInfo)
+	return info, ok
+}
@@ -0,0 +1,46 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeoIP(t *testing.T) {
+	lookup := func(ip string) (GeoInfo, error) {
+		if ip == "81.2.69.142" {
+			return GeoInfo{Country: "GB", Region: "ENG", City: "London"}, nil
+		}
+		return GeoInfo{}, errors.New("not found")
+	}
+	e := NewServeMux()
+	assert.NoError(t, e.SetTrustedProxies("192.0.2.1"))
+	e.Use(GeoIP(lookup))
+	e.GET("/", func(c Context) error {
+		info, ok := Geo(c)
+		if !ok {
+			return c.String(http.StatusOK, "unknown")
+		}
+		return c.String(http.StatusOK, info.Country+"/"+info.Region+"/"+info.City)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderXForwardedFor, "81.2.69.142")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "GB/ENG/London", rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "unknown", rec.Body.String())
+}
+
+func TestGeoIPWithoutLookup(t *testing.T) {
+	assert.Panics(t, func() {
+		GeoIPWithConfig(GeoIPConfig{})
+	})
+}
@@ -20,12 +20,21 @@ func (g *Group) Use(middleware ...MiddlewareFunc) {
 	// Allow all requests to reach the group as they might get dropped if router
 	// doesn't find a match, making none of the group middleware process.
 	for _, p := range []string{"", "/*"} {
-		g.mux.Any(path.Clean(g.prefix+p), func(c Context) error {
-			return NotFoundHandler(c)
-		}, g.middleware...)
+		for _, m := range methods {
+			r := g.mux.add(m, path.Clean(g.prefix+p), func(c Context) error {
+				return g.mux.router.notFoundHandler(getPath(c.Request()))(c)
+			}, g, "", g.middleware...)
+			r.groupCatchAll = true
+		}
 	}
 }
 
+// Middleware implements `Mux#Middleware()` for the group-level middleware,
+// including those inherited from parent groups.
+func (g *Group) Middleware() []string {
+	return middlewareNames(g.middleware)
+}
+
 // CONNECT implements `Mux#CONNECT()` for sub-routes within the Group.
 func (g *Group) CONNECT(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
 	return g.Add(http.MethodConnect, path, h, m...)
@@ -115,5 +124,5 @@ func (g *Group) Add(method, path string, handler HandlerFunc, middleware ...Midd
 	m := make([]MiddlewareFunc, 0, len(g.middleware)+len(middleware))
 	m = append(m, g.middleware...)
 	m = append(m, middleware...)
-	return g.mux.Add(method, g.prefix+path, handler, m...)
+	return g.mux.add(method, g.prefix+path, handler, g, callerSource(), m...)
 }
@@ -55,3 +55,56 @@ func TestGroupRouteMiddleware(t *testing.T) {
 	c, _ = request(http.MethodGet, "/group/405", e)
 	assert.Equal(t, 405, c)
 }
+
+func TestGroupRouteCollision(t *testing.T) {
+	h := func(Context) error { return nil }
+
+	e := NewServeMux()
+	e.Group("/api").GET("/x", h)
+	assert.PanicsWithValue(t, "route: GET /api/x registered at group_test.go:65 conflicts with the route registered at group_test.go:63", func() {
+		e.GET("/api/x", h)
+	})
+
+	e = NewServeMux()
+	e.GET("/api/x", h)
+	g := e.Group("/api")
+	assert.Panics(t, func() { g.GET("/x", h) })
+	assert.Panics(t, func() { e.Group("").GET("/api/x", h) })
+
+	// Replacing routes of the same group or mux
+	assert.NotPanics(t, func() {
+		e.GET("/api/x", h)
+		g.GET("/y", h)
+		g.GET("/y", h)
+		g.Use(func(c Context, next HandlerFunc) error { return next(c) })
+		g.Use(func(c Context, next HandlerFunc) error { return next(c) })
+	})
+}
+
+func TestGroupPrefixParams(t *testing.T) {
+	e := NewServeMux()
+	g := e.Group("/tenants/:tenant")
+	tenant := ""
+	g.Use(func(c Context, next HandlerFunc) error {
+		tenant = c.Param("tenant")
+		return next(c)
+	})
+	g.GET("", func(c Context) error {
+		return c.String(http.StatusOK, "tenant="+c.Param("tenant"))
+	})
+	users := g.Group("/users/:id")
+	users.GET("/posts/:post", func(c Context) error {
+		return c.String(http.StatusOK, c.Param("tenant")+" "+c.Param("id")+" "+c.Param("post"))
+	})
+
+	_, body := request(http.MethodGet, "/tenants/acme", e)
+	assert.Equal(t, "tenant=acme", body)
+	_, body = request(http.MethodGet, "/tenants/acme/users/1/posts/2", e)
+	assert.Equal(t, "acme 1 2", body)
+
+	// Group middleware sees the param for unmatched paths as well
+	tenant = ""
+	code, _ := request(http.MethodGet, "/tenants/umbrella/unknown", e)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "umbrella", tenant)
+}
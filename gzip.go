@@ -0,0 +1,225 @@
+package route
+
+import (
+	"bufio"
+	"compress/gzip"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GzipConfig defines the config for Gzip middleware.
+type GzipConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper Skipper
+
+	// Level is the gzip compression level. Default gzip.DefaultCompression.
+	Level int
+}
+
+// DefaultGzipConfig is the default Gzip middleware config.
+var DefaultGzipConfig = GzipConfig{
+	Skipper: DefaultSkipper,
+	Level:   gzip.DefaultCompression,
+}
+
+// DecompressConfig defines the config for Decompress middleware.
+type DecompressConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper Skipper
+
+	// MaxSize is the maximum size in bytes of decompressed request bodies,
+	// which protects against small bodies expanding to huge ones. Requests
+	// whose body exceeds it are rejected with
+	// `ErrStatusRequestEntityTooLarge`. Default 10 MB.
+	MaxSize int64
+}
+
+// DefaultDecompressConfig is the default Decompress middleware config.
+var DefaultDecompressConfig = DecompressConfig{
+	Skipper: DefaultSkipper,
+	MaxSize: 10 << 20,
+}
+
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	c           Context
+	level       int
+	gz          *gzip.Writer
+	wroteHeader bool
+}
+
+// Gzip returns a middleware which compresses responses with gzip for clients
+// accepting it. Whether to compress is decided when the response is written, so
+// handlers and route-level middleware can still opt out, e.g. using
+// `IdentityEncoding()` or by setting a Content-Encoding themselves.
+func Gzip() MiddlewareFunc {
+	return GzipWithConfig(DefaultGzipConfig)
+}
+
+// GzipWithConfig returns a Gzip middleware with config.
+// See: `Gzip()`.
+func GzipWithConfig(config GzipConfig) MiddlewareFunc {
+	if config.Skipper == nil {
+		config.Skipper = DefaultGzipConfig.Skipper
+	}
+	if config.Level == 0 {
+		config.Level = DefaultGzipConfig.Level
+	}
+
+	return func(c Context, next HandlerFunc) error {
+		if config.Skipper(c) {
+			return next(c)
+		}
+
+		res := c.Response()
+		res.Header().Add(HeaderVary, HeaderAcceptEncoding)
+		rw := res.Writer
+		w := &gzipResponseWriter{ResponseWriter: rw, c: c, level: config.Level}
+		res.Writer = w
+		defer func() {
+			if w.gz != nil {
+				w.gz.Close()
+			}
+			res.Writer = rw
+		}()
+		return next(c)
+	}
+}
+
+// IdentityEncoding returns a middleware which sets the Accept-Encoding header of
+// the request to identity, so that neither the Gzip middleware nor upstreams the
+// request is proxied to compress the response.
+func IdentityEncoding() MiddlewareFunc {
+	return func(c Context, next HandlerFunc) error {
+		c.Request().Header.Set(HeaderAcceptEncoding, "identity")
+		return next(c)
+	}
+}
+
+// Decompress returns a middleware which transparently decompresses request
+// bodies sent with `Content-Encoding: gzip`, so that handlers and the Binder
+// read the decompressed data. The Content-Encoding and Content-Length headers
+// are removed from the request. Requests whose body doesn't start with a valid
+// gzip header are rejected with `ErrBadRequest`, those whose decompressed body
+// is larger than MaxSize, default 10 MB, with
+// `ErrStatusRequestEntityTooLarge`.
+func Decompress() MiddlewareFunc {
+	return DecompressWithConfig(DefaultDecompressConfig)
+}
+
+// DecompressWithConfig returns a Decompress middleware with config.
+// See: `Decompress()`.
+func DecompressWithConfig(config DecompressConfig) MiddlewareFunc {
+	if config.Skipper == nil {
+		config.Skipper = DefaultDecompressConfig.Skipper
+	}
+	if config.MaxSize == 0 {
+		config.MaxSize = DefaultDecompressConfig.MaxSize
+	}
+
+	return func(c Context, next HandlerFunc) error {
+		if config.Skipper(c) {
+			return next(c)
+		}
+
+		req := c.Request()
+		if !strings.EqualFold(strings.TrimSpace(req.Header.Get(HeaderContentEncoding)), "gzip") {
+			return next(c)
+		}
+		body := req.Body
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return NewHTTPError(http.StatusBadRequest, "invalid gzip body").SetInternal(err)
+		}
+		defer func() {
+			gz.Close()
+			body.Close()
+		}()
+		limited := &maxBytesReader{r: gz, n: config.MaxSize}
+		req.Body = limited
+		req.ContentLength = -1
+		req.Header.Del(HeaderContentEncoding)
+		req.Header.Del(HeaderContentLength)
+		err = next(c)
+		if limited.n < 0 {
+			// The handler only read a truncated body
+			return ErrStatusRequestEntityTooLarge
+		}
+		return err
+	}
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		h := w.Header()
+		if code != http.StatusNoContent && code != http.StatusNotModified &&
+			h.Get(HeaderContentEncoding) == "" && acceptsGzip(w.c.Request()) {
+			h.Del(HeaderContentLength)
+			h.Set(HeaderContentEncoding, "gzip")
+			w.gz, _ = gzip.NewWriterLevel(w.ResponseWriter, w.level)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *gzipResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	return h.Hijack()
+}
+
+func (w *gzipResponseWriter) Push(target string, opts *http.PushOptions) error {
+	p, ok := w.ResponseWriter.(http.Pusher)
+	if !ok {
+		return http.ErrNotSupported
+	}
+	return p.Push(target, opts)
+}
+
+func (w *gzipResponseWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// acceptsGzip reports whether the Accept-Encoding header of r allows gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, e := range strings.Split(r.Header.Get(HeaderAcceptEncoding), ",") {
+		parts := strings.Split(e, ";")
+		name := strings.ToLower(strings.TrimSpace(parts[0]))
+		if name != "gzip" && name != "*" {
+			continue
+		}
+		q := 1.0
+		for _, p := range parts[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if v, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			return true
+		}
+	}
+	return false
+}
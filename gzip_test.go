@@ -0,0 +1,151 @@
+package route
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGzip(t *testing.T) {
+	e := NewServeMux()
+	e.Use(Gzip())
+	h := func(c Context) error {
+		return c.String(http.StatusOK, "test")
+	}
+	e.GET("/", h)
+	e.GET("/upstream", h, IdentityEncoding())
+
+	// Compressed
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderAcceptEncoding, "gzip, deflate")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "gzip", rec.Header().Get(HeaderContentEncoding))
+	assert.Equal(t, HeaderAcceptEncoding, rec.Header().Get(HeaderVary))
+	r, err := gzip.NewReader(rec.Body)
+	if assert.NoError(t, err) {
+		b, _ := ioutil.ReadAll(r)
+		assert.Equal(t, "test", string(b))
+	}
+
+	// Not accepted
+	for _, accept := range []string{"", "deflate", "gzip;q=0"} {
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(HeaderAcceptEncoding, accept)
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, "", rec.Header().Get(HeaderContentEncoding), accept)
+		assert.Equal(t, "test", rec.Body.String(), accept)
+	}
+
+	// Identity route
+	req = httptest.NewRequest(http.MethodGet, "/upstream", nil)
+	req.Header.Set(HeaderAcceptEncoding, "gzip")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "", rec.Header().Get(HeaderContentEncoding))
+	assert.Equal(t, "test", rec.Body.String())
+}
+
+func TestGzipNoContent(t *testing.T) {
+	e := NewServeMux()
+	e.Use(Gzip())
+	e.GET("/", func(c Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderAcceptEncoding, "gzip")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "", rec.Header().Get(HeaderContentEncoding))
+	assert.Equal(t, 0, rec.Body.Len())
+}
+
+func TestGzipEncodedByHandler(t *testing.T) {
+	e := NewServeMux()
+	e.Use(Gzip())
+	e.GET("/", func(c Context) error {
+		c.Response().Header().Set(HeaderContentEncoding, "br")
+		return c.Blob(http.StatusOK, MIMEOctetStream, []byte("brotli"))
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderAcceptEncoding, "gzip, br")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "br", rec.Header().Get(HeaderContentEncoding))
+	assert.True(t, bytes.Equal([]byte("brotli"), rec.Body.Bytes()))
+}
+
+func TestDecompress(t *testing.T) {
+	e := NewServeMux()
+	e.Use(Decompress())
+	e.POST("/", func(c Context) error {
+		u := new(user)
+		if err := c.Bind(u); err != nil {
+			return err
+		}
+		assert.Empty(t, c.Request().Header.Get(HeaderContentEncoding))
+		return c.JSON(http.StatusOK, u)
+	})
+
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	gz.Write([]byte(userJSON))
+	gz.Close()
+	req := httptest.NewRequest(http.MethodPost, "/", buf)
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	req.Header.Set(HeaderContentEncoding, "gzip")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, userJSON, rec.Body.String())
+
+	// Malformed
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(userJSON))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	req.Header.Set(HeaderContentEncoding, "gzip")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Uncompressed
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(userJSON))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, userJSON, rec.Body.String())
+}
+
+func TestDecompressMaxSize(t *testing.T) {
+	e := NewServeMux()
+	e.Use(DecompressWithConfig(DecompressConfig{MaxSize: 1024}))
+	e.POST("/", func(c Context) error {
+		b, err := ioutil.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, strconv.Itoa(len(b)))
+	})
+	post := func(size int) *httptest.ResponseRecorder {
+		buf := new(bytes.Buffer)
+		gz := gzip.NewWriter(buf)
+		gz.Write(make([]byte, size))
+		gz.Close()
+		req := httptest.NewRequest(http.MethodPost, "/", buf)
+		req.Header.Set(HeaderContentEncoding, "gzip")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, "1024", post(1024).Body.String())
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(1025).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(1<<20).Code)
+}
@@ -0,0 +1,104 @@
+package route
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// IPFilterConfig defines the config for IPFilter middleware.
+type IPFilterConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper Skipper
+
+	// AllowList is the list of IPs and CIDR ranges, e.g. "10.0.0.0/8", which
+	// may access the resource. All IPs are allowed if empty.
+	AllowList []string
+
+	// DenyList is the list of IPs and CIDR ranges which are denied access,
+	// even if they are allowed by AllowList.
+	DenyList []string
+
+	// TrustedProxies is the list of IPs and CIDR ranges of the reverse
+	// proxies whose `X-Forwarded-For` and `X-Real-IP` headers are used to
+	// determine the client IP, like `Mux#SetTrustedProxies()` does for
+	// `Context#RealIP()`. The remote address of the request is used if
+	// empty.
+	TrustedProxies []string
+}
+
+// IPFilter returns a middleware which rejects requests from IPs which are in
+// the DenyList or not in a non-empty AllowList with `ErrForbidden`, as well as
+// requests whose IP can't be parsed. The IP is the remote address of the
+// request, or the client IP forwarded by one of the TrustedProxies. It returns
+// an error if an entry of the lists is neither an IP nor a CIDR range.
+//
+//	filter, err := route.IPFilter(route.IPFilterConfig{AllowList: []string{"203.0.113.0/24"}})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	admin := mux.Group("/admin", filter)
+func IPFilter(config IPFilterConfig) (MiddlewareFunc, error) {
+	if config.Skipper == nil {
+		config.Skipper = DefaultSkipper
+	}
+	allow, err := parseIPNets(config.AllowList)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := parseIPNets(config.DenyList)
+	if err != nil {
+		return nil, err
+	}
+	trusted, err := parseIPNets(config.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c Context, next HandlerFunc) error {
+		if config.Skipper(c) {
+			return next(c)
+		}
+
+		ip := net.ParseIP(realIP(c.Request(), trusted))
+		if ip == nil || containsIP(deny, ip) || len(allow) > 0 && !containsIP(allow, ip) {
+			return ErrForbidden
+		}
+		return next(c)
+	}, nil
+}
+
+// parseIPNets parses IPs and CIDR ranges. IPs are converted to ranges
+// containing only the IP.
+func parseIPNets(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if strings.IndexByte(e, '/') >= 0 {
+			_, n, err := net.ParseCIDR(e)
+			if err != nil {
+				return nil, fmt.Errorf("route: invalid CIDR range %q", e)
+			}
+			nets = append(nets, n)
+			continue
+		}
+		ip := net.ParseIP(e)
+		if ip == nil {
+			return nil, fmt.Errorf("route: invalid IP %q", e)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+		nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+	}
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPFilter(t *testing.T) {
+	filter, err := IPFilter(IPFilterConfig{
+		AllowList: []string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32"},
+		DenyList:  []string{"10.0.0.13"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	e := NewServeMux()
+	e.Use(filter)
+	e.GET("/", func(c Context) error { return c.String(http.StatusOK, "OK") })
+
+	tests := []struct {
+		ip   string
+		code int
+	}{
+		{"10.1.2.3", http.StatusOK},
+		{"192.0.2.1", http.StatusOK},
+		{"2001:db8::1", http.StatusOK},
+		{"10.0.0.13", http.StatusForbidden},
+		{"192.0.2.2", http.StatusForbidden},
+		{"unknown", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tt.ip
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, tt.code, rec.Code, tt.ip)
+	}
+
+	// Forwarding headers of untrusted clients are ignored
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.2:1234"
+	req.Header.Set(HeaderXForwardedFor, "10.1.2.3")
+	req.Header.Set(HeaderXRealIP, "10.1.2.3")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Trusted proxies
+	filter, err = IPFilter(IPFilterConfig{
+		AllowList:      []string{"10.0.0.0/8"},
+		TrustedProxies: []string{"192.0.2.0/24"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	e = NewServeMux()
+	e.Use(filter)
+	e.GET("/", func(c Context) error { return c.String(http.StatusOK, "OK") })
+	for _, tt := range []struct {
+		remoteAddr, xff string
+		code            int
+	}{
+		{"192.0.2.2:1234", "10.1.2.3", http.StatusOK},
+		{"192.0.2.2:1234", "10.1.2.3, 203.0.113.1", http.StatusForbidden},
+		{"192.0.2.2:1234", "203.0.113.1, 10.1.2.3, 192.0.2.3", http.StatusOK},
+		{"203.0.113.1:1234", "10.1.2.3", http.StatusForbidden},
+	} {
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tt.remoteAddr
+		req.Header.Set(HeaderXForwardedFor, tt.xff)
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, tt.code, rec.Code, tt.remoteAddr+" "+tt.xff)
+	}
+	_, err = IPFilter(IPFilterConfig{TrustedProxies: []string{"proxy"}})
+	assert.EqualError(t, err, `route: invalid IP "proxy"`)
+
+	// Deny list only
+	filter, _ = IPFilter(IPFilterConfig{DenyList: []string{"10.0.0.13"}})
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	assert.NoError(t, filter(c, func(Context) error { return nil }))
+
+	_, err = IPFilter(IPFilterConfig{AllowList: []string{"10.0.0.0/33"}})
+	assert.EqualError(t, err, `route: invalid CIDR range "10.0.0.0/33"`)
+	_, err = IPFilter(IPFilterConfig{DenyList: []string{"localhost"}})
+	assert.EqualError(t, err, `route: invalid IP "localhost"`)
+}
@@ -0,0 +1,44 @@
+package route
+
+import "encoding/json"
+
+type (
+	// JSONSerializer encodes and decodes JSON, e.g. to replace encoding/json
+	// with a faster implementation. It is used by `Context#JSON()` and the
+	// DefaultBinder.
+	JSONSerializer interface {
+		// Serialize writes i encoded as JSON to the response of c, indented
+		// with indent unless it's empty. It should not write anything if i
+		// can't be encoded, so that the error can still be sent instead.
+		Serialize(c Context, i interface{}, indent string) error
+
+		// Deserialize decodes the JSON request body of c into i.
+		Deserialize(c Context, i interface{}) error
+	}
+
+	// defaultJSONSerializer implements JSONSerializer using encoding/json.
+	defaultJSONSerializer struct{}
+)
+
+// Serialize implements the `JSONSerializer#Serialize` function.
+func (defaultJSONSerializer) Serialize(c Context, i interface{}, indent string) error {
+	var (
+		b   []byte
+		err error
+	)
+	if indent != "" {
+		b, err = json.MarshalIndent(i, "", indent)
+	} else {
+		b, err = json.Marshal(i)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = c.Response().Write(b)
+	return err
+}
+
+// Deserialize implements the `JSONSerializer#Deserialize` function.
+func (defaultJSONSerializer) Deserialize(c Context, i interface{}) error {
+	return json.NewDecoder(c.Request().Body).Decode(i)
+}
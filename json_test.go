@@ -0,0 +1,59 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingJSONSerializer wraps the default serializer and records its calls.
+type recordingJSONSerializer struct {
+	defaultJSONSerializer
+	calls []string
+}
+
+func (s *recordingJSONSerializer) Serialize(c Context, i interface{}, indent string) error {
+	s.calls = append(s.calls, "serialize")
+	return s.defaultJSONSerializer.Serialize(c, i, indent)
+}
+
+func (s *recordingJSONSerializer) Deserialize(c Context, i interface{}) error {
+	s.calls = append(s.calls, "deserialize")
+	return s.defaultJSONSerializer.Deserialize(c, i)
+}
+
+func TestJSONSerializer(t *testing.T) {
+	s := new(recordingJSONSerializer)
+	e := NewServeMux(WithJSONSerializer(s))
+	e.POST("/", func(c Context) error {
+		u := new(user)
+		if err := c.Bind(u); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusCreated, u)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(userJSON))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, MIMEApplicationJSONCharsetUTF8, rec.Header().Get(HeaderContentType))
+	assert.Equal(t, userJSON, rec.Body.String())
+	assert.Equal(t, []string{"deserialize", "serialize"}, s.calls)
+}
+
+func TestJSONSerializerError(t *testing.T) {
+	e := NewServeMux()
+	e.GET("/", func(c Context) error {
+		return c.JSON(http.StatusCreated, make(chan bool))
+	})
+
+	// Nothing is sent, so the error handler responds
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
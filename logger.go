@@ -0,0 +1,128 @@
+package route
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Log levels of the Logger middleware in increasing order of severity.
+// Requests logged at LogLevelOff are never written.
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+	LogLevelOff   = "off"
+)
+
+var logLevels = map[string]int{
+	LogLevelDebug: 0,
+	LogLevelInfo:  1,
+	LogLevelWarn:  2,
+	LogLevelError: 3,
+	LogLevelOff:   4,
+}
+
+// LoggerConfig defines the config for Logger middleware.
+type LoggerConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper Skipper
+
+	// Output is where the log lines are written. Default os.Stdout.
+	Output io.Writer
+
+	// Level is the minimum level of the logged requests. Default LogLevelInfo.
+	Level string
+}
+
+// DefaultLoggerConfig is the default Logger middleware config.
+var DefaultLoggerConfig = LoggerConfig{
+	Skipper: DefaultSkipper,
+	Level:   LogLevelInfo,
+}
+
+// LogLevel sets the level the Logger middleware logs requests to the route at,
+// e.g. LogLevelDebug for noisy health checks or LogLevelOff to silence them.
+// Requests are logged at LogLevelInfo by default. It panics on unknown levels.
+func (r *Route) LogLevel(level string) *Route {
+	mustLogLevel(level)
+	r.logLevel = level
+	return r
+}
+
+// Logger returns a middleware which logs a line per request.
+func Logger() MiddlewareFunc {
+	return LoggerWithConfig(DefaultLoggerConfig)
+}
+
+// LoggerWithConfig returns a Logger middleware with config. It panics if
+// config.Level is unknown. Errors of the handler are returned to be handled by
+// the HTTP error handler, so the status logged for them is the one the default
+// HTTP error handler responds with.
+// See: `Logger()`.
+func LoggerWithConfig(config LoggerConfig) MiddlewareFunc {
+	if config.Skipper == nil {
+		config.Skipper = DefaultLoggerConfig.Skipper
+	}
+	if config.Output == nil {
+		config.Output = os.Stdout
+	}
+	if config.Level == "" {
+		config.Level = DefaultLoggerConfig.Level
+	}
+	min := mustLogLevel(config.Level)
+
+	return func(c Context, next HandlerFunc) error {
+		if config.Skipper(c) {
+			return next(c)
+		}
+
+		start := time.Now()
+		err := next(c)
+
+		level := LogLevelInfo
+		route := c.RouteInfo()
+		if route != nil && route.logLevel != "" {
+			level = route.logLevel
+		}
+		if logLevels[level] < min || level == LogLevelOff {
+			return err
+		}
+
+		req := c.Request()
+		path := req.URL.Path
+		if route != nil {
+			path = route.Path
+		}
+		status := c.Response().Status
+		if err != nil && !c.Response().Committed {
+			status = errorStatus(c.Mux(), err)
+		}
+		fmt.Fprintf(config.Output, "time=%s level=%s method=%s path=%s uri=%s status=%d latency=%s\n",
+			start.Format(time.RFC3339), level, req.Method, path, req.RequestURI, status, time.Since(start))
+		return err
+	}
+}
+
+// errorStatus returns the status code the default HTTP error handler of mux
+// responds to err with.
+func errorStatus(mux *Mux, err error) int {
+	if he, ok := err.(*HTTPError); ok {
+		return he.Code
+	}
+	if m := mux.mapError(err); m != nil {
+		return m.code
+	}
+	return http.StatusInternalServerError
+}
+
+func mustLogLevel(level string) int {
+	l, ok := logLevels[level]
+	if !ok {
+		panic("route: unknown log level " + level)
+	}
+	return l
+}
@@ -0,0 +1,74 @@
+package route
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := NewServeMux()
+	e.Use(LoggerWithConfig(LoggerConfig{Output: buf}))
+	h := func(c Context) error { return c.String(http.StatusOK, "OK") }
+	e.GET("/users/:id", h)
+	e.GET("/health", h).LogLevel(LogLevelOff)
+	e.GET("/ready", h).LogLevel(LogLevelDebug)
+	e.GET("/fail", func(c Context) error { return errors.New("failed") }).LogLevel(LogLevelError)
+
+	// Silenced routes
+	request(http.MethodGet, "/health", e)
+	request(http.MethodGet, "/ready", e)
+	assert.Empty(t, buf.String())
+
+	request(http.MethodGet, "/users/1", e)
+	assert.Contains(t, buf.String(), "level=info method=GET path=/users/:id uri=/users/1 status=200")
+
+	buf.Reset()
+	code, _ := request(http.MethodGet, "/fail", e)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Contains(t, buf.String(), "level=error method=GET path=/fail uri=/fail status=500")
+}
+
+func TestLoggerError(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := NewServeMux()
+	var err error
+	e.Use(func(c Context, next HandlerFunc) error {
+		err = next(c)
+		return err
+	})
+	e.Use(LoggerWithConfig(LoggerConfig{Output: buf}))
+	e.GET("/missing", func(c Context) error { return ErrNotFound })
+
+	// The error is passed on to the middleware wrapping Logger
+	code, _ := request(http.MethodGet, "/missing", e)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, ErrNotFound, err)
+	assert.Contains(t, buf.String(), "status=404")
+}
+
+func TestLoggerLevel(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := NewServeMux()
+	e.Use(LoggerWithConfig(LoggerConfig{Output: buf, Level: LogLevelDebug}))
+	e.GET("/ready", func(c Context) error { return c.NoContent(http.StatusOK) }).LogLevel(LogLevelDebug)
+
+	request(http.MethodGet, "/ready", e)
+	assert.Contains(t, buf.String(), "level=debug")
+}
+
+func TestLoggerUnknownLevel(t *testing.T) {
+	assert.PanicsWithValue(t, "route: unknown log level verbose", func() {
+		LoggerWithConfig(LoggerConfig{Level: "verbose"})
+	})
+
+	e := NewServeMux()
+	r := e.GET("/", func(c Context) error { return nil })
+	assert.PanicsWithValue(t, "route: unknown log level Debug", func() {
+		r.LogLevel("Debug")
+	})
+}
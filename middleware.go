@@ -27,6 +27,15 @@ func DefaultSkipper(Context) bool {
 	return false
 }
 
+// middlewareNames returns the function names of middleware.
+func middlewareNames(middleware []MiddlewareFunc) []string {
+	names := make([]string, len(middleware))
+	for i, m := range middleware {
+		names[i] = handlerName(m)
+	}
+	return names
+}
+
 // compose chains given handler with next middleware.
 func compose(h HandlerFunc, m MiddlewareFunc) HandlerFunc {
 	return func(c Context) error {
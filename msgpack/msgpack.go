@@ -0,0 +1,77 @@
+// Package msgpack adds MessagePack request and response support to route. It is
+// kept separate from the core package, which has no external runtime
+// dependencies.
+package msgpack
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/goroute/route"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var defaultBinder = &route.DefaultBinder{}
+
+// Binder is a route.Binder which decodes request bodies with the
+// `application/msgpack` Content-Type and delegates any other request to
+// Fallback.
+//
+//	mux := route.NewServeMux(route.WithBinder(&msgpack.Binder{}))
+type Binder struct {
+	// Fallback binds requests without a MessagePack body. Default
+	// route.DefaultBinder.
+	Fallback route.Binder
+}
+
+// Bind implements the `route.Binder#Bind` function.
+func (b *Binder) Bind(i interface{}, c route.Context) error {
+	if isMsgpack(c.Request()) {
+		return Bind(c, i)
+	}
+	if b.Fallback != nil {
+		return b.Fallback.Bind(i, c)
+	}
+	return defaultBinder.Bind(i, c)
+}
+
+// Bind decodes the MessagePack request body into i. It returns
+// route.ErrUnsupportedMediaType if the request has another Content-Type.
+func Bind(c route.Context, i interface{}) error {
+	req := c.Request()
+	if !isMsgpack(req) {
+		return route.ErrUnsupportedMediaType
+	}
+	if req.ContentLength == 0 {
+		return route.NewHTTPError(http.StatusBadRequest, "Request body can't be empty")
+	}
+	if err := msgpack.NewDecoder(req.Body).Decode(i); err != nil {
+		return route.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	return nil
+}
+
+// Send sends i encoded as MessagePack as a response with status code.
+func Send(c route.Context, code int, i interface{}) error {
+	b, err := msgpack.Marshal(i)
+	if err != nil {
+		return err
+	}
+	return c.Blob(code, route.MIMEApplicationMsgpack, b)
+}
+
+// Negotiate sends i as MessagePack if the Accept header of the request prefers
+// it over the representations of route.Context#Negotiate, which is used
+// otherwise.
+func Negotiate(c route.Context, code int, i interface{}, template ...string) error {
+	accept := c.Request().Header.Get(route.HeaderAccept)
+	offers := []string{route.MIMEApplicationJSON, route.MIMEApplicationXML, route.MIMETextXML, route.MIMETextHTML, route.MIMEApplicationMsgpack}
+	if accept != "" && route.NegotiateContentType(accept, offers) == route.MIMEApplicationMsgpack {
+		return Send(c, code, i)
+	}
+	return c.Negotiate(code, i, template...)
+}
+
+func isMsgpack(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get(route.HeaderContentType), route.MIMEApplicationMsgpack)
+}
@@ -0,0 +1,88 @@
+package msgpack
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goroute/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type user struct {
+	ID   int    `msgpack:"id" json:"id"`
+	Name string `msgpack:"name" json:"name"`
+}
+
+func TestSend(t *testing.T) {
+	mux := route.NewServeMux()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := mux.NewContext(req, rec)
+
+	err := Send(c, http.StatusOK, user{1, "Jon Snow"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, route.MIMEApplicationMsgpack, rec.Header().Get(route.HeaderContentType))
+		u := user{}
+		if assert.NoError(t, msgpack.Unmarshal(rec.Body.Bytes(), &u)) {
+			assert.Equal(t, user{1, "Jon Snow"}, u)
+		}
+	}
+}
+
+func TestBind(t *testing.T) {
+	body, _ := msgpack.Marshal(user{1, "Jon Snow"})
+	mux := route.NewServeMux(route.WithBinder(&Binder{}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set(route.HeaderContentType, route.MIMEApplicationMsgpack)
+	c := mux.NewContext(req, httptest.NewRecorder())
+	u := user{}
+	if assert.NoError(t, c.Bind(&u)) {
+		assert.Equal(t, user{1, "Jon Snow"}, u)
+	}
+
+	// Invalid body
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("\xc1"))
+	req.Header.Set(route.HeaderContentType, route.MIMEApplicationMsgpack)
+	c = mux.NewContext(req, httptest.NewRecorder())
+	err := c.Bind(&u)
+	if assert.IsType(t, new(route.HTTPError), err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*route.HTTPError).Code)
+	}
+
+	// Fallback
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":2,"name":"Arya Stark"}`))
+	req.Header.Set(route.HeaderContentType, route.MIMEApplicationJSON)
+	c = mux.NewContext(req, httptest.NewRecorder())
+	if assert.NoError(t, c.Bind(&u)) {
+		assert.Equal(t, user{2, "Arya Stark"}, u)
+	}
+	assert.Equal(t, route.ErrUnsupportedMediaType, Bind(c, &u))
+}
+
+func TestNegotiate(t *testing.T) {
+	mux := route.NewServeMux()
+	tests := []struct {
+		accept string
+		ctype  string
+	}{
+		{"", route.MIMEApplicationJSONCharsetUTF8},
+		{"application/msgpack", route.MIMEApplicationMsgpack},
+		{"application/json, application/msgpack;q=0.5", route.MIMEApplicationJSONCharsetUTF8},
+		{"application/xml", route.MIMEApplicationXMLCharsetUTF8},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(route.HeaderAccept, tt.accept)
+		rec := httptest.NewRecorder()
+		c := mux.NewContext(req, rec)
+		if assert.NoError(t, Negotiate(c, http.StatusOK, user{1, "Jon Snow"}), tt.accept) {
+			assert.Equal(t, tt.ctype, rec.Header().Get(route.HeaderContentType), tt.accept)
+		}
+	}
+}
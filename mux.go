@@ -2,33 +2,86 @@
 package route
 
 import (
+	"bytes"
+	stdcontext "context"
 	"errors"
 	"fmt"
 	"html/template"
 	"io"
+	"net"
 	"net/http"
-	"net/url"
 	"path"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"text/tabwriter"
 )
 
 type (
 	// Mux is the top-level framework instance.
 	Mux struct {
-		premiddleware   []MiddlewareFunc
-		middleware      []MiddlewareFunc
-		maxParam        *int
-		router          *router
-		notFoundHandler HandlerFunc
-		pool            sync.Pool
+		premiddleware           []MiddlewareFunc
+		middleware              []MiddlewareFunc
+		matched                 []MiddlewareFunc
+		maxParam                *int
+		router                  *router
+		notFoundHandler         HandlerFunc
+		methodNotAllowedHandler HandlerFunc
+		pool                    *sync.Pool
+		chain                   atomic.Value // HandlerFunc composed by Build
+		server                  atomic.Value // *http.Server started by Start
+		draining                int32        // set atomically by Shutdown
+		errorMappings           []errorMapping
+		trustedProxies          []*net.IPNet
 
 		Debug            bool
 		HTTPErrorHandler HTTPErrorHandler
 		Binder           Binder
+		Validator        Validator
+		Sanitizer        Sanitizer
 		Renderer         Renderer
+		// JSONSerializer encodes JSON responses and decodes JSON request
+		// bodies. Default encoding/json.
+		JSONSerializer JSONSerializer
+		// DefaultContentType is assumed when binding a request body sent
+		// without a Content-Type header.
+		DefaultContentType string
+		// ErrorTemplate is the template the default HTTP error handler renders
+		// the *HTTPError with for clients preferring HTML, if a Renderer is
+		// registered. Default "error.html".
+		ErrorTemplate string
+		// ErrorLogger is called by the default HTTP error handler before the
+		// response is written for errors resulting in a 5xx status, e.g. to
+		// report them to an error tracker. The Internal error of HTTPErrors
+		// is passed if set.
+		ErrorLogger func(c Context, err error)
+		// LogClientErrors makes the default HTTP error handler pass errors
+		// resulting in a 4xx status to ErrorLogger as well.
+		LogClientErrors bool
+		// FlashSecret is the secret the cookie storing flash messages is
+		// signed with. Required for `Context#SetFlash()` and `Context#Flashes()`.
+		FlashSecret []byte
+		// BodyLimit is the maximum size in bytes of request bodies read into
+		// memory by `Context#BodyBytes()` and `Context#BodyReader()`. Default
+		// 4 MB.
+		BodyLimit int64
+		// MultipartMemoryLimit is the number of bytes of multipart form files
+		// kept in memory, the remainder is stored in temporary files on disk.
+		// Default 32 MB.
+		MultipartMemoryLimit int64
+		// MergeSlashes merges repeated slashes in request paths before routing,
+		// e.g. "/users//1" is matched as "/users/1".
+		MergeSlashes bool
+		// RejectRepeatedSlashes makes requests with repeated slashes in their
+		// path not found, including those a wildcard route would match. By
+		// default such paths are matched as they are, but params never match
+		// the empty segment between two slashes, so "/users//posts" isn't
+		// found for "/users/:id/posts". MergeSlashes takes precedence.
+		RejectRepeatedSlashes bool
 	}
 
 	// Route contains a handler and information for matching against requests.
@@ -36,6 +89,14 @@ type (
 		Method string `json:"method"`
 		Path   string `json:"path"`
 		Name   string `json:"name"`
+
+		logLevel string
+		cors     *CORSConfig
+		meta     map[string]interface{}
+		owner    *Group // nil for routes registered with the Mux
+		source   string // file:line the route was registered at
+
+		groupCatchAll bool // Registered by Group.Use to run group middleware
 	}
 
 	// HTTPError represents an error that occurred while handling a request.
@@ -51,11 +112,29 @@ type (
 	// HTTPErrorHandler is a centralized HTTP error handler.
 	HTTPErrorHandler func(error, Context)
 
+	// Validator is the interface that wraps the Validate function.
+	Validator interface {
+		Validate(i interface{}) error
+	}
+
+	// Sanitizer is the interface that wraps the Sanitize function. It is used
+	// to strip dangerous HTML markup from bound fields tagged with
+	// `sanitize:"html"`, e.g. using an HTML sanitizer library.
+	Sanitizer interface {
+		Sanitize(s string) string
+	}
+
 	// Renderer is the interface that wraps the Render function.
 	Renderer interface {
 		Render(io.Writer, string, interface{}, Context) error
 	}
 
+	// LayoutRenderer is implemented by Renderers which can render a template into
+	// the "content" block of a layout template.
+	LayoutRenderer interface {
+		RenderLayout(w io.Writer, layout, name string, data interface{}, c Context) error
+	}
+
 	// i is the interface for Mux and Group.
 	i interface {
 		GET(string, HandlerFunc, ...MiddlewareFunc) *Route
@@ -80,6 +159,8 @@ const (
 	MIMETextPlain                        = "text/plain"
 	MIMETextPlainCharsetUTF8             = MIMETextPlain + "; " + charsetUTF8
 	MIMEMultipartForm                    = "multipart/form-data"
+	MIMEMultipartMixed                   = "multipart/mixed"
+	MIMEMultipartByteranges              = "multipart/byteranges"
 	MIMEOctetStream                      = "application/octet-stream"
 )
 
@@ -95,15 +176,22 @@ const (
 	HeaderAcceptEncoding      = "Accept-Encoding"
 	HeaderAllow               = "Allow"
 	HeaderAuthorization       = "Authorization"
+	HeaderCacheControl        = "Cache-Control"
 	HeaderContentDisposition  = "Content-Disposition"
 	HeaderContentEncoding     = "Content-Encoding"
 	HeaderContentLength       = "Content-Length"
+	HeaderContentLanguage     = "Content-Language"
 	HeaderContentType         = "Content-Type"
 	HeaderCookie              = "Cookie"
+	HeaderETag                = "ETag"
 	HeaderSetCookie           = "Set-Cookie"
 	HeaderIfModifiedSince     = "If-Modified-Since"
+	HeaderIfNoneMatch         = "If-None-Match"
 	HeaderLastModified        = "Last-Modified"
 	HeaderLocation            = "Location"
+	HeaderLink                = "Link"
+	HeaderRange               = "Range"
+	HeaderContentRange        = "Content-Range"
 	HeaderUpgrade             = "Upgrade"
 	HeaderVary                = "Vary"
 	HeaderWWWAuthenticate     = "WWW-Authenticate"
@@ -160,9 +248,11 @@ var (
 	ErrUnauthorized                = NewHTTPError(http.StatusUnauthorized)
 	ErrForbidden                   = NewHTTPError(http.StatusForbidden)
 	ErrMethodNotAllowed            = NewHTTPError(http.StatusMethodNotAllowed)
+	ErrNotAcceptable               = NewHTTPError(http.StatusNotAcceptable)
 	ErrStatusRequestEntityTooLarge = NewHTTPError(http.StatusRequestEntityTooLarge)
 	ErrTooManyRequests             = NewHTTPError(http.StatusTooManyRequests)
 	ErrBadRequest                  = NewHTTPError(http.StatusBadRequest)
+	ErrUnprocessableEntity         = NewHTTPError(http.StatusUnprocessableEntity)
 	ErrBadGateway                  = NewHTTPError(http.StatusBadGateway)
 	ErrInternalServerError         = NewHTTPError(http.StatusInternalServerError)
 	ErrRequestTimeout              = NewHTTPError(http.StatusRequestTimeout)
@@ -171,6 +261,10 @@ var (
 	ErrRendererNotRegistered       = errors.New("Renderer not registered")
 	ErrInvalidRedirectCode         = errors.New("invalid redirect status code")
 	ErrCookieNotFound              = errors.New("cookie not found")
+	ErrCookieTampered              = errors.New("cookie signature invalid")
+	ErrClientDisconnected          = errors.New("client disconnected")
+	ErrTooManyForwards             = errors.New("too many internal forwards")
+	ErrFlashSecretNotSet           = errors.New("flash secret not set")
 )
 
 // Error handlers
@@ -184,10 +278,21 @@ var (
 	}
 )
 
+// errorMapping is an error translation registered with Mux.MapError.
+type errorMapping struct {
+	target  error
+	code    int
+	message interface{}
+}
+
 type options struct {
-	binder           Binder
-	renderer         Renderer
-	httpErrorHandler HTTPErrorHandler
+	binder             Binder
+	validator          Validator
+	sanitizer          Sanitizer
+	renderer           Renderer
+	httpErrorHandler   HTTPErrorHandler
+	defaultContentType string
+	jsonSerializer     JSONSerializer
 }
 
 // A Option sets options such as credentials, tls, etc.
@@ -200,6 +305,22 @@ func WithBinder(binder Binder) Option {
 	}
 }
 
+// WithValidator allows to register mux Validator, which validates bound
+// request data.
+func WithValidator(validator Validator) Option {
+	return func(o *options) {
+		o.validator = validator
+	}
+}
+
+// WithSanitizer allows to register mux Sanitizer, which sanitizes bound string
+// fields tagged with `sanitize:"html"`.
+func WithSanitizer(sanitizer Sanitizer) Option {
+	return func(o *options) {
+		o.sanitizer = sanitizer
+	}
+}
+
 // WithRenderer allows to register mux view Renderer.
 func WithRenderer(renderer Renderer) Option {
 	return func(o *options) {
@@ -214,6 +335,22 @@ func WithHTTPErrorHandler(handler HTTPErrorHandler) Option {
 	}
 }
 
+// WithDefaultContentType sets the content type assumed when binding a request
+// body sent without a Content-Type header, e.g. `MIMEApplicationJSON`.
+func WithDefaultContentType(ctype string) Option {
+	return func(o *options) {
+		o.defaultContentType = ctype
+	}
+}
+
+// WithJSONSerializer allows to override the default JSONSerializer, which uses
+// encoding/json.
+func WithJSONSerializer(serializer JSONSerializer) Option {
+	return func(o *options) {
+		o.jsonSerializer = serializer
+	}
+}
+
 // NewServeMux creates an instance of mux.
 func NewServeMux(opt ...Option) (e *Mux) {
 	opts := options{
@@ -225,9 +362,14 @@ func NewServeMux(opt ...Option) (e *Mux) {
 	}
 
 	e = &Mux{
-		maxParam: new(int),
-		Binder:   opts.binder,
-		Renderer: opts.renderer,
+		maxParam:           new(int),
+		Binder:             opts.binder,
+		Validator:          opts.validator,
+		Sanitizer:          opts.sanitizer,
+		Renderer:           opts.renderer,
+		JSONSerializer:     opts.jsonSerializer,
+		DefaultContentType: opts.defaultContentType,
+		ErrorTemplate:      "error.html",
 	}
 
 	// http error handler must be set after mux instance.
@@ -237,13 +379,44 @@ func NewServeMux(opt ...Option) (e *Mux) {
 		e.HTTPErrorHandler = e.defaultHTTPErrorHandler
 	}
 
-	e.pool.New = func() interface{} {
+	e.pool = &sync.Pool{New: func() interface{} {
 		return e.NewContext(nil, nil)
-	}
+	}}
 	e.router = newRouter(e)
 	return
 }
 
+// Clone returns a new Mux with the configuration, middleware, constraints and
+// prefix not found handlers of mux, but without its routes. The middleware
+// slices are copied, so middleware added to either Mux later doesn't affect the
+// other. If mux uses the default HTTP error handler, the clone uses its own,
+// which reads the configuration of the clone.
+//
+// This allows building a base Mux with common middleware and forking it per
+// test or tenant.
+func (mux *Mux) Clone() *Mux {
+	e := new(Mux)
+	*e = *mux
+	e.premiddleware = append([]MiddlewareFunc(nil), mux.premiddleware...)
+	e.middleware = append([]MiddlewareFunc(nil), mux.middleware...)
+	e.matched = append([]MiddlewareFunc(nil), mux.matched...)
+	e.errorMappings = append([]errorMapping(nil), mux.errorMappings...)
+
+	// Reset the state which mustn't be shared
+	e.maxParam = new(int)
+	e.chain = atomic.Value{}
+	e.server = atomic.Value{}
+	e.draining = 0
+	if reflect.ValueOf(mux.HTTPErrorHandler).Pointer() == reflect.ValueOf(mux.defaultHTTPErrorHandler).Pointer() {
+		e.HTTPErrorHandler = e.defaultHTTPErrorHandler
+	}
+	e.pool = &sync.Pool{New: func() interface{} {
+		return e.NewContext(nil, nil)
+	}}
+	e.router = mux.router.clone(e)
+	return e
+}
+
 // NewContext returns a Context instance.
 func (mux *Mux) NewContext(r *http.Request, w http.ResponseWriter) Context {
 	return &context{
@@ -256,14 +429,64 @@ func (mux *Mux) NewContext(r *http.Request, w http.ResponseWriter) Context {
 	}
 }
 
+// jsonSerializer returns the registered JSONSerializer or the default one.
+func (mux *Mux) jsonSerializer() JSONSerializer {
+	if mux.JSONSerializer != nil {
+		return mux.JSONSerializer
+	}
+	return defaultJSONSerializer{}
+}
+
+// RenderToString renders the template name with data using the registered
+// Renderer and returns the output instead of sending it, e.g. to build the body
+// of an email. It returns `ErrRendererNotRegistered` if no Renderer is
+// registered.
+func (mux *Mux) RenderToString(name string, data interface{}, c Context) (string, error) {
+	if mux.Renderer == nil {
+		return "", ErrRendererNotRegistered
+	}
+	buf := new(bytes.Buffer)
+	if err := mux.Renderer.Render(buf, name, data, c); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
 // Pre adds middleware to the chain which is run before router.
 func (mux *Mux) Pre(middleware ...MiddlewareFunc) {
 	mux.premiddleware = append(mux.premiddleware, middleware...)
+	mux.chain.Store(HandlerFunc(nil))
 }
 
 // Use adds middleware to the chain which is run after router.
 func (mux *Mux) Use(middleware ...MiddlewareFunc) {
 	mux.middleware = append(mux.middleware, middleware...)
+	mux.chain.Store(HandlerFunc(nil))
+}
+
+// UseMatched adds middleware to the chain which is run after router and the
+// middleware added with `Use()`, but only for requests matching a route. Unlike
+// middleware added with `Use()`, it doesn't run for requests which aren't
+// found or whose method isn't allowed, e.g. to keep auth checks and their logs
+// free of 404 noise. Middleware added with `Pre()` runs before routing for all
+// requests.
+func (mux *Mux) UseMatched(middleware ...MiddlewareFunc) {
+	mux.matched = append(mux.matched, middleware...)
+	mux.chain.Store(HandlerFunc(nil))
+}
+
+// PreMiddleware returns the function names of the middleware added with
+// `Pre()` in execution order.
+func (mux *Mux) PreMiddleware() []string {
+	return middlewareNames(mux.premiddleware)
+}
+
+// Middleware returns the function names of the middleware added with `Use()`
+// followed by those added with `UseMatched()`, in execution order. Middleware
+// returned by a constructor is named after the closure, e.g.
+// "github.com/goroute/route.CORSWithConfig.func1".
+func (mux *Mux) Middleware() []string {
+	return append(middlewareNames(mux.middleware), middlewareNames(mux.matched)...)
 }
 
 // CONNECT registers a new CONNECT route for a path with matching handler in the
@@ -350,20 +573,23 @@ func (mux *Mux) Static(prefix, root string) *Route {
 }
 
 func static(i i, prefix, root string) *Route {
-	h := func(c Context) error {
-		p, err := url.PathUnescape(c.Param("*"))
-		if err != nil {
-			return err
-		}
-		name := filepath.Join(root, path.Clean("/"+p)) // "/"+ for security
-		return c.File(name)
+	return addStatic(i, prefix, func(c Context, name string) error {
+		return c.File(filepath.Join(root, name))
+	})
+}
+
+// addStatic registers h for prefix and every path below it. h receives the
+// unescaped and cleaned path of the requested file relative to prefix.
+func addStatic(i i, prefix string, h func(c Context, name string) error) *Route {
+	handler := func(c Context) error {
+		return h(c, path.Clean("/"+c.ParamWildcard())) // "/"+ for security
 	}
-	i.GET(prefix, h)
+	i.GET(prefix, handler)
 	if prefix == "/" {
-		return i.GET(prefix+"*", h)
+		return i.GET(prefix+"*", handler)
 	}
 
-	return i.GET(prefix+"/*", h)
+	return i.GET(prefix+"/*", handler)
 }
 
 // File registers a new route with path to serve a static file with optional route-level middleware.
@@ -373,34 +599,178 @@ func (mux *Mux) File(path, file string, m ...MiddlewareFunc) *Route {
 	}, m...)
 }
 
+// Mount registers sub to handle all requests with path prefix, e.g. another
+// Mux built by a separate module:
+//
+//	parent.Mount("/api", apiMux)
+//
+// The prefix is stripped from the request path before it is passed to sub, so
+// "/api/users" is handled by sub as "/users" and "/api" as "/". Pre middleware
+// of mux runs before, as well as the middleware added with Use.
+func (mux *Mux) Mount(prefix string, sub http.Handler) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	h := func(c Context) error {
+		r := c.Request().Clone(c.Request().Context())
+		r.URL.Path = "/" + c.ParamWildcard()
+		if r.URL.RawPath != "" {
+			r.URL.RawPath = "/" + c.Param("*")
+		}
+		sub.ServeHTTP(c.Response(), r)
+		return nil
+	}
+	if prefix != "" {
+		mux.Any(prefix, h)
+	}
+	mux.Any(prefix+"/*", h)
+}
+
 // Add registers a new route for an HTTP method and path with matching handler
 // in the router with optional route-level middleware.
+//
+// A param may be constrained by a regular expression, e.g.
+// "/users/:id([0-9]+)", or by a named constraint, e.g. "/users/:id{uuid}", see
+// AddConstraint. Params with different patterns at the same position are tried
+// in the order they were registered, a param without a pattern last.
+//
+// A query string appended to the path, e.g. "/export?type=pdf", only matches
+// requests carrying those query parameters.
+//
+// Registering a route again replaces it. It panics if the route was registered
+// by a group with an overlapping prefix, or vice versa.
 func (mux *Mux) Add(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) *Route {
-	name := handlerName(handler)
-	mux.router.add(method, path, func(c Context) error {
-		h := handler
-		// Chain middleware
-		for i := len(middleware) - 1; i >= 0; i-- {
-			h = compose(h, middleware[i])
-		}
-		return h(c)
-	})
+	return mux.add(method, path, handler, nil, callerSource(), middleware...)
+}
+
+// add registers a route for method and path by owner, the group or nil for
+// the mux, at source. Routes registered without a source may be replaced by,
+// and replace, routes of any owner.
+func (mux *Mux) add(method, path string, handler HandlerFunc, owner *Group, source string, middleware ...MiddlewareFunc) *Route {
+	if prev, ok := mux.router.routes[method+path]; ok && prev.source != "" && source != "" && prev.owner != owner {
+		panic(fmt.Sprintf("route: %s %s registered at %s conflicts with the route registered at %s", method, path, source, prev.source))
+	}
 	r := &Route{
 		Method: method,
 		Path:   path,
-		Name:   name,
+		Name:   handlerName(handler),
+		owner:  owner,
+		source: source,
 	}
+	// Chain middleware once, the global middleware is composed by Build
+	h := handler
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = compose(h, middleware[i])
+	}
+	mux.router.addRoute(r, h)
 	mux.router.routes[method+path] = r
+	mux.router.addName(r)
 	return r
 }
 
+// AddConstraint registers a named param constraint, which restricts params
+// followed by its name in braces to values matching pattern in full, e.g.
+// "/orders/:id{int}" after AddConstraint("int", "[0-9]+"). The constraints
+// "uuid" and "slug" ([a-z0-9-]+) are built in. It panics if pattern doesn't
+// compile.
+func (mux *Mux) AddConstraint(name, pattern string) {
+	compilePattern(name, pattern)
+	mux.router.constraints[name] = pattern
+}
+
+// SetNotFoundHandler sets the handler for requests which don't match any route,
+// e.g. to send custom 404 pages. Default `NotFoundHandler`. Handlers registered
+// with `NotFoundForPrefix()` take precedence for their prefix.
+func (mux *Mux) SetNotFoundHandler(h HandlerFunc) {
+	mux.notFoundHandler = h
+}
+
+// SetMethodNotAllowedHandler sets the handler for requests to paths with routes
+// for other methods only. Default `MethodNotAllowedHandler`.
+func (mux *Mux) SetMethodNotAllowedHandler(h HandlerFunc) {
+	mux.methodNotAllowedHandler = h
+}
+
+// notFound returns the handler for requests which don't match any route.
+func (mux *Mux) notFound() HandlerFunc {
+	if mux.notFoundHandler != nil {
+		return mux.notFoundHandler
+	}
+	return NotFoundHandler
+}
+
+// methodNotAllowed returns the handler for requests with a method no route is
+// registered for at their path.
+func (mux *Mux) methodNotAllowed() HandlerFunc {
+	if mux.methodNotAllowedHandler != nil {
+		return mux.methodNotAllowedHandler
+	}
+	return MethodNotAllowedHandler
+}
+
+// NotFoundForPrefix registers h to handle the requests to prefix and the paths
+// below it which don't match any route, e.g. to send JSON errors for "/api"
+// and HTML pages otherwise. The handler of the longest matching prefix is used.
+func (mux *Mux) NotFoundForPrefix(prefix string, h HandlerFunc) {
+	mux.router.addNotFound(prefix, h)
+}
+
+// SetTrustedProxies sets the IPs and CIDR ranges, e.g. "10.0.0.0/8", of the
+// reverse proxies whose `X-Forwarded-For` and `X-Real-IP` headers are used by
+// `Context#RealIP()`. It returns an error if an entry is neither an IP nor a
+// CIDR range. No proxies are trusted by default.
+func (mux *Mux) SetTrustedProxies(ranges ...string) error {
+	nets, err := parseIPNets(ranges)
+	if err != nil {
+		return err
+	}
+	mux.trustedProxies = nets
+	return nil
+}
+
+// MapError registers the HTTP status code and message the default HTTP error
+// handler responds with for errors matching target according to errors.Is,
+// e.g. to respond to sql.ErrNoRows with a 404, so that handlers can return
+// plain domain errors. The message defaults to the status text if nil.
+// Mappings are consulted in the order they were registered, and only for
+// errors which aren't an *HTTPError.
+func (mux *Mux) MapError(target error, code int, message interface{}) {
+	if message == nil {
+		message = http.StatusText(code)
+	}
+	mux.errorMappings = append(mux.errorMappings, errorMapping{target, code, message})
+}
+
 // Group creates a new router group with prefix and optional group-level middleware.
+// The prefix may contain params, e.g. "/tenants/:tenant", whose values are
+// available to the routes and middleware of the group via `Context#Param()`.
 func (mux *Mux) Group(prefix string, m ...MiddlewareFunc) (g *Group) {
 	g = &Group{prefix: prefix, mux: mux}
 	g.Use(m...)
 	return
 }
 
+// Set attaches metadata val under key to the route, e.g. for middleware which
+// reads it using `Context#RouteInfo()`:
+//
+//	mux.GET("/admin", h).Set("requireRole", "admin")
+//
+// It must only be called while registering routes, before serving requests.
+func (r *Route) Set(key string, val interface{}) *Route {
+	if r.meta == nil {
+		r.meta = make(map[string]interface{})
+	}
+	r.meta[key] = val
+	return r
+}
+
+// Get returns the metadata attached to the route under key, or nil. It may be
+// called on a nil Route, e.g. the RouteInfo of requests which matched no route.
+func (r *Route) Get(key string) interface{} {
+	if r == nil {
+		return nil
+	}
+	return r.meta[key]
+}
+
 // Routes returns the registered routes.
 func (mux *Mux) Routes() []*Route {
 	routes := make([]*Route, 0, len(mux.router.routes))
@@ -410,46 +780,135 @@ func (mux *Mux) Routes() []*Route {
 	return routes
 }
 
-// ServeHTTP implements `http.Handler` interface, which serves HTTP requests.
-func (mux *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Acquire context
-	c := mux.pool.Get().(*context)
-	c.reset(r, w)
+// RoutesSorted returns the registered routes sorted by path, then method.
+func (mux *Mux) RoutesSorted() []*Route {
+	routes := mux.Routes()
+	sort.Slice(routes, func(i, j int) bool {
+		return routeLess(routes[i], routes[j])
+	})
+	return routes
+}
 
-	var h HandlerFunc
+// routeLess reports whether route a sorts before b by path, then method.
+func routeLess(a, b *Route) bool {
+	if a.Path != b.Path {
+		return a.Path < b.Path
+	}
+	return a.Method < b.Method
+}
 
-	if mux.premiddleware == nil {
-		mux.router.find(r.Method, getPath(r), c)
-		h = c.Handler()
-		for i := len(mux.middleware) - 1; i >= 0; i-- {
-			h = compose(h, mux.middleware[i])
+// PrintRoutes writes the registered routes to w as a table of method, path
+// and handler name, sorted like `RoutesSorted()`.
+func (mux *Mux) PrintRoutes(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tHANDLER")
+	for _, r := range mux.RoutesSorted() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Method, r.Path, r.Name)
+	}
+	return tw.Flush()
+}
+
+// Reverse returns the path of the route named name with its params replaced by
+// params in order, e.g. Reverse("user", 1) returns "/users/1" for the route
+// "/users/:id" named "user". Values of regular params are path escaped, those
+// of the '*' wildcard aren't. Optional params without a value are omitted. It
+// returns an empty string if there's no route named name.
+//
+//	mux.GET("/users/:id", getUser).Name = "user"
+func (mux *Mux) Reverse(name string, params ...interface{}) string {
+	r := mux.router.named(name)
+	if r == nil {
+		return ""
+	}
+	return reversePath(r.Path, params)
+}
+
+// findRoute returns the route matching method and the path of r, or nil.
+func (mux *Mux) findRoute(method string, r *http.Request) *Route {
+	c := mux.pool.Get().(*context)
+	c.reset(r, nil)
+	mux.router.find(method, getPath(r), c)
+	route := c.route
+	mux.pool.Put(c)
+	return route
+}
+
+// Build composes the middleware chain run for every request. It is called on
+// the first request after middleware was added with `Pre()`, `Use()` or
+// `UseMatched()`, but can be called upfront to avoid the cost on the first
+// request.
+func (mux *Mux) Build() HandlerFunc {
+	var h HandlerFunc = func(c Context) error {
+		return c.Handler()(c)
+	}
+	if len(mux.matched) > 0 {
+		matched := h
+		for i := len(mux.matched) - 1; i >= 0; i-- {
+			matched = compose(matched, mux.matched[i])
 		}
-	} else {
 		h = func(c Context) error {
-			mux.router.find(r.Method, getPath(r), c)
-			h := c.Handler()
-			for i := len(mux.middleware) - 1; i >= 0; i-- {
-				h = compose(h, mux.middleware[i])
+			if r := c.RouteInfo(); r != nil && !r.groupCatchAll {
+				return matched(c)
 			}
-			return h(c)
+			return c.Handler()(c)
+		}
+	}
+	for i := len(mux.middleware) - 1; i >= 0; i-- {
+		h = compose(h, mux.middleware[i])
+	}
+	if mux.premiddleware != nil {
+		// Route after the pre-middleware, which may rewrite the request
+		next := h
+		h = func(c Context) error {
+			r := c.Request()
+			mux.router.find(r.Method, getPath(r), c)
+			return next(c)
 		}
 		for i := len(mux.premiddleware) - 1; i >= 0; i-- {
 			h = compose(h, mux.premiddleware[i])
 		}
 	}
+	mux.chain.Store(h)
+	return h
+}
+
+// ServeHTTP implements `http.Handler` interface, which serves HTTP requests.
+func (mux *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h, _ := mux.chain.Load().(HandlerFunc)
+	if h == nil {
+		h = mux.Build()
+	}
+
+	// Acquire context
+	c := mux.pool.Get().(*context)
+	c.reset(r, w)
+
+	if mux.premiddleware == nil {
+		mux.router.find(r.Method, getPath(r), c)
+	}
 
 	// Execute chain
 	if err := h(c); err != nil {
 		mux.HTTPErrorHandler(err, c)
 	}
+	c.response.runAfter()
+
+	// Remove the temporary files of multipart forms
+	if form := c.request.MultipartForm; form != nil {
+		form.RemoveAll()
+	}
 
 	// Release context
 	mux.pool.Put(c)
 }
 
 // defaultHTTPErrorHandler is the default HTTP error handler. It sends a JSON response
-// with status code.
+// with status code, or renders `Mux#ErrorTemplate` for clients preferring HTML.
+// `ErrClientDisconnected` is ignored, as there is no one to respond to.
 func (mux *Mux) defaultHTTPErrorHandler(err error, c Context) {
+	if errors.Is(err, ErrClientDisconnected) {
+		return
+	}
 	var (
 		code = http.StatusInternalServerError
 		msg  interface{}
@@ -458,36 +917,114 @@ func (mux *Mux) defaultHTTPErrorHandler(err error, c Context) {
 	if he, ok := err.(*HTTPError); ok {
 		code = he.Code
 		msg = he.Message
-		if he.Internal != nil {
-			err = fmt.Errorf("%v, %v", err, he.Internal)
-		}
+	} else if m := mux.mapError(err); m != nil {
+		code = m.code
+		msg = m.message
 	} else if mux.Debug {
 		msg = err.Error()
 	} else {
 		msg = http.StatusText(code)
 	}
+	if mux.ErrorLogger != nil && (code >= 500 || mux.LogClientErrors && code >= 400) {
+		logErr := err
+		if he, ok := err.(*HTTPError); ok && he.Internal != nil {
+			logErr = he.Internal
+		}
+		mux.ErrorLogger(c, logErr)
+	}
+	he := &HTTPError{Code: code, Message: msg}
 	if _, ok := msg.(string); ok {
 		msg = map[string]interface{}{"message": msg}
 	}
 
 	// Send response
 	if !c.Response().Committed {
-		if c.Request().Method == http.MethodHead {
+		if c.Request().Method == http.MethodHead || code == http.StatusNoContent || code == http.StatusNotModified {
 			_ = c.NoContent(code)
-		} else {
+		} else if !mux.prefersHTML(c) || c.Render(code, mux.ErrorTemplate, he) != nil {
 			_ = c.JSON(code, msg)
 		}
 	}
 }
 
-// WrapHandler wraps `http.Handler` into `mux.HandlerFunc`.
+// mapError returns the first mapping registered with MapError matching err.
+func (mux *Mux) mapError(err error) *errorMapping {
+	for i := range mux.errorMappings {
+		if errors.Is(err, mux.errorMappings[i].target) {
+			return &mux.errorMappings[i]
+		}
+	}
+	return nil
+}
+
+// prefersHTML reports whether an error page can be rendered and the client
+// prefers HTML over JSON.
+func (mux *Mux) prefersHTML(c Context) bool {
+	if mux.Renderer == nil || mux.ErrorTemplate == "" {
+		return false
+	}
+	accept := c.Request().Header.Get(HeaderAccept)
+	return NegotiateContentType(accept, []string{MIMEApplicationJSON, MIMETextHTML}) == MIMETextHTML
+}
+
+// pkgDir is the directory of the package's source files.
+var pkgDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// callerSource returns the file:line of the first caller outside of the
+// package, skipping the registration methods of Mux and Group.
+func callerSource() string {
+	pc := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pc[:runtime.Callers(2, pc)])
+	for {
+		f, more := frames.Next()
+		if filepath.Dir(f.File) != pkgDir || strings.HasSuffix(f.File, "_test.go") {
+			return fmt.Sprintf("%s:%d", filepath.Base(f.File), f.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// contextKey is the type of the keys route stores values under in the
+// `context.Context` of requests.
+type contextKey struct {
+	name string
+}
+
+// ParamsContextKey is the key the path params of the route are stored under in
+// the context of requests passed to handlers wrapped by `WrapHandler()`, as a
+// map[string]string of names to values. See `ParamFromRequest()`.
+var ParamsContextKey = &contextKey{"params"}
+
+// WrapHandler wraps `http.Handler` into `mux.HandlerFunc`. The path params of
+// the route are available to h via `ParamFromRequest()`.
 func WrapHandler(h http.Handler) HandlerFunc {
 	return func(c Context) error {
-		h.ServeHTTP(c.Response(), c.Request())
+		r := c.Request()
+		if len(c.ParamNames()) > 0 {
+			r = r.WithContext(stdcontext.WithValue(r.Context(), ParamsContextKey, c.Params()))
+		}
+		h.ServeHTTP(c.Response(), r)
 		return nil
 	}
 }
 
+// ParamFromRequest returns the path param name of a request passed to a handler
+// wrapped by `WrapHandler()`, easing the migration of net/http handlers.
+func ParamFromRequest(r *http.Request, name string) string {
+	params, _ := r.Context().Value(ParamsContextKey).(map[string]string)
+	return params[name]
+}
+
+// getPath returns the path routes are matched against. This is the escaped
+// path if it differs from the default encoding of the path, so that escaped
+// slashes like in "/a%2Fb" don't separate segments, and the unescaped path
+// otherwise. Param values are therefore escaped only if the request path
+// contains such escapes, which `Context#ParamWildcard()` accounts for.
 func getPath(r *http.Request) string {
 	rawPath := r.URL.RawPath
 	if rawPath == "" {
@@ -496,7 +1033,8 @@ func getPath(r *http.Request) string {
 	return rawPath
 }
 
-func handlerName(h HandlerFunc) string {
+// handlerName returns the function name of the handler or middleware h.
+func handlerName(h interface{}) string {
 	t := reflect.ValueOf(h).Type()
 	if t.Kind() == reflect.Func {
 		return runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
@@ -505,16 +1043,65 @@ func handlerName(h HandlerFunc) string {
 }
 
 // NewDefaultTemplateRenderer creates default template Renderer with given pattern.
-func NewDefaultTemplateRenderer(pattern string) *templateRenderer {
+// Optional function maps are registered on the templates before parsing.
+func NewDefaultTemplateRenderer(pattern string, funcs ...template.FuncMap) *templateRenderer {
+	t := template.New("")
+	for _, fm := range funcs {
+		t = t.Funcs(fm)
+	}
+	base := template.Must(t.ParseGlob(pattern))
 	return &templateRenderer{
-		templates: template.Must(template.ParseGlob(pattern)),
+		templates: template.Must(base.Clone()),
+		base:      base,
+		layouts:   make(map[string]*template.Template),
 	}
 }
 
 type templateRenderer struct {
 	templates *template.Template
+	// base is never executed as html/template can't clone executed templates.
+	base    *template.Template
+	mu      sync.RWMutex
+	layouts map[string]*template.Template
 }
 
 func (t *templateRenderer) Render(w io.Writer, name string, data interface{}, c Context) error {
 	return t.templates.ExecuteTemplate(w, name, data)
 }
+
+// RenderLayout implements `LayoutRenderer#RenderLayout` by executing layout with
+// its "content" block defined as the named template.
+func (t *templateRenderer) RenderLayout(w io.Writer, layout, name string, data interface{}, c Context) error {
+	tmpl, err := t.layout(layout, name)
+	if err != nil {
+		return err
+	}
+	return tmpl.ExecuteTemplate(w, layout, data)
+}
+
+func (t *templateRenderer) layout(layout, name string) (*template.Template, error) {
+	key := layout + ":" + name
+	t.mu.RLock()
+	tmpl, ok := t.layouts[key]
+	t.mu.RUnlock()
+	if ok {
+		return tmpl, nil
+	}
+
+	content := t.base.Lookup(name)
+	if content == nil {
+		return nil, fmt.Errorf("template: no template %q", name)
+	}
+	tmpl, err := t.base.Clone()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tmpl.AddParseTree("content", content.Tree.Copy()); err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.layouts[key] = tmpl
+	t.mu.Unlock()
+	return tmpl, nil
+}
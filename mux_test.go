@@ -3,6 +3,8 @@ package route
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"html/template"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -23,6 +25,7 @@ type (
 
 const (
 	userJSON            = `{"id":1,"name":"Jon Snow"}`
+	userXML             = `<user><id>1</id><name>Jon Snow</name></user>`
 	userForm            = `id=1&name=Jon Snow`
 	invalidContent      = "invalid content"
 	userJSONInvalidType = `{"id":"1","name":"Jon Snow"}`
@@ -43,6 +46,144 @@ func TestMux(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 }
 
+func TestMuxMapError(t *testing.T) {
+	e := NewServeMux()
+	errNoRows := errors.New("no rows")
+	errConflict := errors.New("conflict")
+	e.MapError(errNoRows, http.StatusNotFound, nil)
+	e.MapError(errConflict, http.StatusConflict, map[string]string{"error": "already exists"})
+	var logged []error
+	e.ErrorLogger = func(c Context, err error) {
+		logged = append(logged, err)
+	}
+	e.GET("/users/:id", func(c Context) error {
+		return fmt.Errorf("find user %s: %w", c.Param("id"), errNoRows)
+	})
+	e.POST("/users", func(c Context) error {
+		return errConflict
+	})
+	e.GET("/http", func(c Context) error {
+		// HTTPErrors aren't mapped
+		return NewHTTPError(http.StatusBadRequest).SetInternal(errNoRows)
+	})
+
+	code, body := request(http.MethodGet, "/users/1", e)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, `{"message":"Not Found"}`, body)
+	code, body = request(http.MethodPost, "/users", e)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, `{"error":"already exists"}`, body)
+	code, _ = request(http.MethodGet, "/http", e)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Empty(t, logged)
+}
+
+func TestMuxErrorLogger(t *testing.T) {
+	e := NewServeMux()
+	var logged []error
+	e.ErrorLogger = func(c Context, err error) {
+		assert.False(t, c.Response().Committed)
+		logged = append(logged, err)
+	}
+	errDB := errors.New("connection refused")
+	e.GET("/internal", func(c Context) error {
+		return NewHTTPError(http.StatusServiceUnavailable).SetInternal(errDB)
+	})
+	e.GET("/error", func(c Context) error {
+		return errors.New("failed")
+	})
+	e.GET("/bad", func(c Context) error {
+		return ErrBadRequest
+	})
+
+	code, body := request(http.MethodGet, "/internal", e)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, `{"message":"Service Unavailable"}`, body)
+	request(http.MethodGet, "/error", e)
+	request(http.MethodGet, "/bad", e)
+	request(http.MethodGet, "/missing", e)
+	if assert.Len(t, logged, 2) {
+		assert.Equal(t, errDB, logged[0])
+		assert.EqualError(t, logged[1], "failed")
+	}
+
+	logged = nil
+	e.LogClientErrors = true
+	request(http.MethodGet, "/bad", e)
+	request(http.MethodGet, "/missing", e)
+	if assert.Len(t, logged, 2) {
+		assert.Equal(t, ErrBadRequest, logged[0])
+		assert.Equal(t, ErrNotFound, logged[1])
+	}
+}
+
+func TestMuxHTTPErrorHandlerNoBody(t *testing.T) {
+	e := NewServeMux()
+	e.Any("/", func(c Context) error {
+		c.Response().Header().Set(HeaderContentType, MIMEApplicationJSON)
+		return NewHTTPError(http.StatusNotModified)
+	})
+	e.HEAD("/forbidden", func(c Context) error {
+		return ErrForbidden
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Header().Get(HeaderContentType))
+	assert.Empty(t, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/forbidden", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Empty(t, rec.Header().Get(HeaderContentType))
+	assert.Empty(t, rec.Header().Get(HeaderContentLength))
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestMuxHTTPErrorHandlerHTML(t *testing.T) {
+	e := NewServeMux(WithRenderer(NewDefaultTemplateRenderer("testdata/templates/*.html", template.FuncMap{
+		"upper": strings.ToUpper,
+	})))
+	e.GET("/", func(c Context) error {
+		return NewHTTPError(http.StatusForbidden, "Go away")
+	})
+	browser := "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderAccept, browser)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, MIMETextHTMLCharsetUTF8, rec.Header().Get(HeaderContentType))
+	assert.Equal(t, "<h1>403</h1><p>Go away</p>", rec.Body.String())
+
+	// API clients
+	for _, accept := range []string{"", "*/*", "application/json"} {
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(HeaderAccept, accept)
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, MIMEApplicationJSONCharsetUTF8, rec.Header().Get(HeaderContentType), accept)
+		assert.Equal(t, `{"message":"Go away"}`, rec.Body.String(), accept)
+	}
+
+	// Missing template
+	e.ErrorTemplate = "missing.html"
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderAccept, browser)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, `{"message":"Go away"}`, rec.Body.String())
+
+	// No renderer
+	e.Renderer = nil
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, `{"message":"Go away"}`, rec.Body.String())
+}
+
 func TestMuxStatic(t *testing.T) {
 	mux := NewServeMux()
 
@@ -93,6 +234,74 @@ func TestMuxWithOptions(t *testing.T) {
 	assert.NotNil(t, mux.HTTPErrorHandler)
 }
 
+func TestMuxDefaultTemplateRenderer(t *testing.T) {
+	r := NewDefaultTemplateRenderer("testdata/templates/*.html", template.FuncMap{
+		"upper": strings.ToUpper,
+	})
+	buf := new(bytes.Buffer)
+	err := r.Render(buf, "hello.html", "Jon Snow", nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Hello, JON SNOW!", buf.String())
+	}
+
+	assert.Panics(t, func() {
+		NewDefaultTemplateRenderer("testdata/templates/*.html")
+	})
+}
+
+func TestMuxRenderToString(t *testing.T) {
+	e := NewServeMux()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	_, err := e.RenderToString("hello.html", "Jon Snow", c)
+	assert.Equal(t, ErrRendererNotRegistered, err)
+
+	e.Renderer = NewDefaultTemplateRenderer("testdata/templates/*.html", template.FuncMap{
+		"upper": strings.ToUpper,
+	})
+	s, err := e.RenderToString("hello.html", "Jon Snow", c)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Hello, JON SNOW!", s)
+	}
+	assert.False(t, c.Response().Committed)
+	assert.Empty(t, rec.Body.String())
+
+	_, err = e.RenderToString("missing.html", nil, c)
+	assert.Error(t, err)
+}
+
+func TestMuxReverse(t *testing.T) {
+	e := NewServeMux()
+	h := func(Context) error { return nil }
+	e.GET("/static", h).Name = "static"
+	e.GET("/users/:id", h).Name = "user"
+	e.GET("/users/:uid/files/*", h).Name = "file"
+	e.GET("/orders/:id([0-9]+)/items/:item{uuid}", h).Name = "item"
+	e.GET("/posts/:year/:month?", h).Name = "posts"
+
+	assert.Equal(t, "/static", e.Reverse("static"))
+	assert.Equal(t, "/users/1", e.Reverse("user", 1))
+	assert.Equal(t, "/users/a%2Fb", e.Reverse("user", "a/b"))
+	assert.Equal(t, "/users/1/files/docs/a.txt", e.Reverse("file", 1, "docs/a.txt"))
+	assert.Equal(t, "/orders/2/items/x", e.Reverse("item", 2, "x"))
+	assert.Equal(t, "/posts/2023/06", e.Reverse("posts", 2023, "06"))
+	assert.Equal(t, "/posts/2023", e.Reverse("posts", 2023))
+	assert.Equal(t, "", e.Reverse("missing"))
+
+	// Renamed and replaced routes
+	r := e.GET("/login", h)
+	assert.Equal(t, r, e.router.names[r.Name])
+	r.Name = "login"
+	assert.Equal(t, "/login", e.Reverse("login"))
+	r.Name = "signin"
+	assert.Equal(t, "", e.Reverse("login"))
+	assert.Equal(t, "/login", e.Reverse("signin"))
+	e.GET("/login", h).Name = "login"
+	assert.Equal(t, "", e.Reverse("signin"))
+	assert.Equal(t, "/login", e.Reverse("login"))
+}
+
 func TestMuxFile(t *testing.T) {
 	mux := NewServeMux()
 	mux.File("/walle", "testdata/images/walle.png")
@@ -138,6 +347,92 @@ func TestMuxMiddleware(t *testing.T) {
 	assert.Equal(t, "OK", b)
 }
 
+func TestMuxMiddlewareRebuild(t *testing.T) {
+	mux := NewServeMux()
+	buf := new(bytes.Buffer)
+	mux.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "OK")
+	})
+	mux.Use(func(c Context, next HandlerFunc) error {
+		buf.WriteString("1")
+		return next(c)
+	})
+	mux.Build()
+
+	request(http.MethodGet, "/", mux)
+	assert.Equal(t, "1", buf.String())
+
+	// Adding middleware invalidates the chain
+	mux.Use(func(c Context, next HandlerFunc) error {
+		buf.WriteString("2")
+		return next(c)
+	})
+	mux.Pre(func(c Context, next HandlerFunc) error {
+		buf.WriteString("-1")
+		return next(c)
+	})
+	buf.Reset()
+	request(http.MethodGet, "/", mux)
+	assert.Equal(t, "-112", buf.String())
+}
+
+func TestMuxRouteMiddleware(t *testing.T) {
+	mux := NewServeMux()
+	buf := new(bytes.Buffer)
+	m := func(s string) MiddlewareFunc {
+		return func(c Context, next HandlerFunc) error {
+			buf.WriteString(s)
+			return next(c)
+		}
+	}
+	mux.Use(m("1"))
+	mux.GET("/", func(c Context) error {
+		buf.WriteString("h")
+		return c.NoContent(http.StatusOK)
+	}, m("a"), m("b"))
+
+	for i := 0; i < 2; i++ {
+		buf.Reset()
+		request(http.MethodGet, "/", mux)
+		assert.Equal(t, "1abh", buf.String())
+	}
+}
+
+func TestMuxUseMatched(t *testing.T) {
+	mux := NewServeMux()
+	buf := new(bytes.Buffer)
+	m := func(s string) MiddlewareFunc {
+		return func(c Context, next HandlerFunc) error {
+			buf.WriteString(s)
+			return next(c)
+		}
+	}
+	mux.UseMatched(m("m"))
+	mux.Use(m("1"))
+	mux.GET("/", func(c Context) error {
+		buf.WriteString("h")
+		return c.NoContent(http.StatusOK)
+	}, m("r"))
+	g := mux.Group("/admin")
+	g.Use(m("g"))
+	assert.True(t, mux.router.routes[http.MethodGet+"/admin/*"].groupCatchAll)
+	assert.False(t, mux.router.routes[http.MethodGet+"/"].groupCatchAll)
+
+	request(http.MethodGet, "/", mux)
+	assert.Equal(t, "1mrh", buf.String())
+
+	for _, tt := range []struct{ method, path string }{
+		{http.MethodGet, "/missing"},
+		{http.MethodPost, "/"},
+		{http.MethodGet, "/admin/missing"},
+	} {
+		buf.Reset()
+		request(tt.method, tt.path, mux)
+		assert.NotContains(t, buf.String(), "m", tt.path)
+		assert.Contains(t, buf.String(), "1", tt.path)
+	}
+}
+
 func TestMuxMiddlewareError(t *testing.T) {
 	mux := NewServeMux()
 	buf := new(bytes.Buffer)
@@ -192,6 +487,25 @@ func TestMuxWrapHandler(t *testing.T) {
 	}
 }
 
+func TestMuxWrapHandlerParams(t *testing.T) {
+	mux := NewServeMux()
+	mux.GET("/users/:id/files/*", WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(ParamFromRequest(r, "id") + " " + ParamFromRequest(r, "*") + " " + ParamFromRequest(r, "missing")))
+	})))
+	mux.GET("/", WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, r.Context().Value(ParamsContextKey))
+		w.Write([]byte(ParamFromRequest(r, "id")))
+	})))
+
+	c, b := request(http.MethodGet, "/users/1/files/a/b", mux)
+	assert.Equal(t, http.StatusOK, c)
+	assert.Equal(t, "1 a/b ", b)
+
+	c, b = request(http.MethodGet, "/", mux)
+	assert.Equal(t, http.StatusOK, c)
+	assert.Empty(t, b)
+}
+
 func TestMuxConnect(t *testing.T) {
 	mux := NewServeMux()
 	testMethod(t, http.MethodConnect, "/", mux)
@@ -254,10 +568,10 @@ func TestMuxMatch(t *testing.T) { // JFC
 func TestMuxRoutes(t *testing.T) {
 	mux := NewServeMux()
 	routes := []*Route{
-		{http.MethodGet, "/users/:user/events", ""},
-		{http.MethodGet, "/users/:user/events/public", ""},
-		{http.MethodPost, "/repos/:owner/:repo/git/refs", ""},
-		{http.MethodPost, "/repos/:owner/:repo/git/tags", ""},
+		{Method: http.MethodGet, Path: "/users/:user/events"},
+		{Method: http.MethodGet, Path: "/users/:user/events/public"},
+		{Method: http.MethodPost, Path: "/repos/:owner/:repo/git/refs"},
+		{Method: http.MethodPost, Path: "/repos/:owner/:repo/git/tags"},
 	}
 	for _, r := range routes {
 		mux.Add(r.Method, r.Path, func(c Context) error {
@@ -281,6 +595,34 @@ func TestMuxRoutes(t *testing.T) {
 	}
 }
 
+func listUsers(c Context) error {
+	return c.NoContent(http.StatusOK)
+}
+
+func TestMuxRoutesSorted(t *testing.T) {
+	mux := NewServeMux()
+	mux.POST("/users", listUsers)
+	mux.GET("/users/:id", listUsers)
+	mux.GET("/users", listUsers)
+	mux.GET("/*", listUsers)
+
+	var got []string
+	for _, r := range mux.RoutesSorted() {
+		got = append(got, r.Method+" "+r.Path)
+	}
+	assert.Equal(t, []string{"GET /*", "GET /users", "POST /users", "GET /users/:id"}, got)
+
+	buf := new(bytes.Buffer)
+	if assert.NoError(t, mux.PrintRoutes(buf)) {
+		h := "github.com/goroute/route.listUsers"
+		assert.Equal(t, "METHOD  PATH        HANDLER\n"+
+			"GET     /*          "+h+"\n"+
+			"GET     /users      "+h+"\n"+
+			"POST    /users      "+h+"\n"+
+			"GET     /users/:id  "+h+"\n", buf.String())
+	}
+}
+
 func TestMuxEncodedPath(t *testing.T) {
 	mux := NewServeMux()
 	mux.GET("/:id", func(c Context) error {
@@ -350,6 +692,34 @@ func TestMuxNotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, rec.Code)
 }
 
+func TestMuxNotFoundForPrefix(t *testing.T) {
+	e := NewServeMux()
+	e.GET("/api/users", func(c Context) error { return c.NoContent(http.StatusOK) })
+	e.NotFoundForPrefix("/", func(c Context) error {
+		return c.HTML(http.StatusNotFound, "<h1>Not Found</h1>")
+	})
+	e.NotFoundForPrefix("/api/", func(c Context) error {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "no such endpoint"})
+	})
+
+	code, body := request(http.MethodGet, "/api/posts", e)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, `{"error":"no such endpoint"}`, body)
+	_, body = request(http.MethodGet, "/api", e)
+	assert.Equal(t, `{"error":"no such endpoint"}`, body)
+	code, body = request(http.MethodGet, "/apidocs", e)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "<h1>Not Found</h1>", body)
+	_, body = request(http.MethodGet, "/about", e)
+	assert.Equal(t, "<h1>Not Found</h1>", body)
+
+	// Matched routes and disallowed methods are unaffected
+	code, _ = request(http.MethodGet, "/api/users", e)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = request(http.MethodPost, "/api/users", e)
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+}
+
 func TestMuxMethodNotAllowed(t *testing.T) {
 	mux := NewServeMux()
 	mux.GET("/", func(c Context) error {
@@ -361,6 +731,30 @@ func TestMuxMethodNotAllowed(t *testing.T) {
 	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
 }
 
+func TestMuxRouteInfo(t *testing.T) {
+	e := NewServeMux()
+	var route *Route
+	e.Use(func(c Context, next HandlerFunc) error {
+		err := next(c)
+		route = c.RouteInfo()
+		return err
+	})
+	h := func(c Context) error { return c.NoContent(http.StatusOK) }
+	users := e.GET("/users/:id", h)
+	users.Name = "user"
+	export := e.GET("/export?type=pdf", h)
+
+	request(http.MethodGet, "/users/1", e)
+	if assert.NotNil(t, route) {
+		assert.True(t, users == route)
+		assert.Equal(t, "user", route.Name)
+	}
+	request(http.MethodGet, "/export?type=pdf", e)
+	assert.True(t, export == route)
+	request(http.MethodGet, "/missing", e)
+	assert.Nil(t, route)
+}
+
 func TestMuxContext(t *testing.T) {
 	mux := NewServeMux()
 	c := mux.pool.Get().(*context)
@@ -414,6 +808,25 @@ func TestHTTPError(t *testing.T) {
 	assert.Equal(t, "code=400, message=map[code:12]", err.Error())
 }
 
+func TestHTTPErrorUnwrap(t *testing.T) {
+	errNoRows := errors.New("no rows")
+	err := NewHTTPError(http.StatusNotFound).SetInternal(fmt.Errorf("find user: %w", errNoRows))
+	assert.True(t, errors.Is(err, errNoRows))
+	assert.Nil(t, NewHTTPError(http.StatusNotFound).Unwrap())
+
+	// Nested
+	inner := NewHTTPError(http.StatusConflict).SetInternal(errNoRows)
+	outer := NewHTTPError(http.StatusInternalServerError).SetInternal(inner)
+	assert.True(t, errors.Is(outer, errNoRows))
+
+	he, ok := AsHTTPError(fmt.Errorf("handler: %w", inner))
+	if assert.True(t, ok) {
+		assert.Equal(t, http.StatusConflict, he.Code)
+	}
+	_, ok = AsHTTPError(errNoRows)
+	assert.False(t, ok)
+}
+
 type mockBinder struct{}
 
 func (mockBinder) Bind(i interface{}, c Context) error {
@@ -425,3 +838,316 @@ type mockRenderer struct{}
 func (mockRenderer) Render(io.Writer, string, interface{}, Context) error {
 	return nil
 }
+
+type benchmarkWriter struct {
+	header http.Header
+}
+
+func (w *benchmarkWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *benchmarkWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *benchmarkWriter) WriteHeader(int) {}
+
+// Composing the middleware chains per request allocated 3, 5 and 8 times for
+// these, respectively.
+func benchmarkMuxServeHTTP(b *testing.B, pre, route bool) {
+	mux := NewServeMux()
+	m := func(c Context, next HandlerFunc) error {
+		return next(c)
+	}
+	if pre {
+		mux.Pre(m)
+	}
+	mux.Use(m, m, m)
+	var rm []MiddlewareFunc
+	if route {
+		rm = append(rm, m, m, m)
+	}
+	mux.GET("/users/:id", func(c Context) error {
+		c.Response().WriteHeader(http.StatusOK)
+		return nil
+	}, rm...)
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	w := &benchmarkWriter{header: make(http.Header)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mux.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkMuxServeHTTP(b *testing.B) {
+	benchmarkMuxServeHTTP(b, false, false)
+}
+
+func BenchmarkMuxServeHTTPPre(b *testing.B) {
+	benchmarkMuxServeHTTP(b, true, false)
+}
+
+func BenchmarkMuxServeHTTPRouteMiddleware(b *testing.B) {
+	benchmarkMuxServeHTTP(b, true, true)
+}
+
+// Reallocating the store of pooled contexts allocated once per request.
+func BenchmarkMuxServeHTTPStore(b *testing.B) {
+	mux := NewServeMux()
+	mux.GET("/users/:id", func(c Context) error {
+		c.Set("found", c.Param("id") != "")
+		c.Response().WriteHeader(http.StatusOK)
+		return nil
+	})
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	w := &benchmarkWriter{header: make(http.Header)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mux.ServeHTTP(w, req)
+	}
+}
+
+func TestMuxMiddlewareNames(t *testing.T) {
+	e := NewServeMux()
+	e.Pre(HTTPSRedirect())
+	e.UseMatched(Decompress())
+	e.Use(Gzip())
+	assert.Equal(t, []string{"github.com/goroute/route.redirect.func1"}, e.PreMiddleware())
+	assert.Equal(t, []string{
+		"github.com/goroute/route.GzipWithConfig.func1",
+		"github.com/goroute/route.DecompressWithConfig.func1",
+	}, e.Middleware())
+
+	g := e.Group("/api", IdentityEncoding())
+	v1 := g.Group("/v1", CORS())
+	assert.Equal(t, []string{"github.com/goroute/route.IdentityEncoding.func1"}, g.Middleware())
+	assert.Equal(t, []string{
+		"github.com/goroute/route.IdentityEncoding.func1",
+		"github.com/goroute/route.CORSWithConfig.func1",
+	}, v1.Middleware())
+
+	assert.Empty(t, NewServeMux().Middleware())
+}
+
+func TestMuxClone(t *testing.T) {
+	base := NewServeMux()
+	base.Debug = true
+	base.Use(func(c Context, next HandlerFunc) error {
+		c.Response().Header().Set("X-Base", "1")
+		return next(c)
+	})
+	base.GET("/base", func(c Context) error {
+		return c.String(http.StatusOK, "base")
+	})
+
+	clone := base.Clone()
+	assert.True(t, clone.Debug)
+	assert.Equal(t, base.Binder, clone.Binder)
+	clone.Use(func(c Context, next HandlerFunc) error {
+		c.Response().Header().Set("X-Clone", "1")
+		return next(c)
+	})
+	clone.GET("/clone", func(c Context) error {
+		return c.String(http.StatusOK, "clone")
+	})
+
+	// Routes aren't shared
+	code, _ := request(http.MethodGet, "/base", clone)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = request(http.MethodGet, "/clone", base)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// Middleware is copied
+	req := httptest.NewRequest(http.MethodGet, "/clone", nil)
+	rec := httptest.NewRecorder()
+	clone.ServeHTTP(rec, req)
+	assert.Equal(t, "clone", rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get("X-Base"))
+	assert.Equal(t, "1", rec.Header().Get("X-Clone"))
+
+	req = httptest.NewRequest(http.MethodGet, "/base", nil)
+	rec = httptest.NewRecorder()
+	base.ServeHTTP(rec, req)
+	assert.Equal(t, "base", rec.Body.String())
+	assert.Empty(t, rec.Header().Get("X-Clone"))
+
+	// Constraints and prefix not found handlers are copied
+	base.AddConstraint("int", "[0-9]+")
+	base.NotFoundForPrefix("/api", func(c Context) error {
+		return c.String(http.StatusNotFound, "api not found")
+	})
+	clone = base.Clone()
+	clone.GET("/orders/:id{int}", func(c Context) error {
+		return c.String(http.StatusOK, c.Param("id"))
+	})
+	code, body := request(http.MethodGet, "/orders/1", clone)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "1", body)
+	code, _ = request(http.MethodGet, "/orders/x", clone)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, body = request(http.MethodGet, "/api/missing", clone)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "api not found", body)
+	clone.AddConstraint("hex", "[0-9a-f]+")
+	assert.Panics(t, func() {
+		base.GET("/colors/:c{hex}", func(c Context) error { return nil })
+	})
+
+	// The default error handler uses the configuration of the clone
+	clone.ErrorLogger = func(c Context, err error) {
+		c.Response().Header().Set("X-Logged", "1")
+	}
+	clone.GET("/fail", func(c Context) error {
+		return errors.New("fail")
+	})
+	req = httptest.NewRequest(http.MethodGet, "/fail", nil)
+	rec = httptest.NewRecorder()
+	clone.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("X-Logged"))
+}
+
+func TestMuxMount(t *testing.T) {
+	api := NewServeMux()
+	api.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "index")
+	})
+	api.GET("/users/:id", func(c Context) error {
+		return c.String(http.StatusOK, "user "+c.Param("id"))
+	})
+	api.POST("/users", func(c Context) error {
+		return c.String(http.StatusCreated, "created")
+	})
+
+	parent := NewServeMux()
+	var pre []string
+	parent.Pre(func(c Context, next HandlerFunc) error {
+		pre = append(pre, c.Request().URL.Path)
+		return next(c)
+	})
+	parent.Mount("/api", api)
+	parent.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "parent")
+	})
+
+	code, body := request(http.MethodGet, "/api/users/1", parent)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "user 1", body)
+
+	code, body = request(http.MethodPost, "/api/users", parent)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, "created", body)
+
+	for _, path := range []string{"/api", "/api/"} {
+		code, body = request(http.MethodGet, path, parent)
+		assert.Equal(t, http.StatusOK, code, path)
+		assert.Equal(t, "index", body, path)
+	}
+
+	code, _ = request(http.MethodGet, "/api/missing", parent)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, body = request(http.MethodGet, "/", parent)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "parent", body)
+
+	assert.Equal(t, []string{"/api/users/1", "/api/users", "/api", "/api/", "/api/missing", "/"}, pre)
+
+	// Escaped slashes are kept
+	var id string
+	api.GET("/files/:id", func(c Context) error {
+		id = c.Param("id")
+		return c.NoContent(http.StatusOK)
+	})
+	code, _ = request(http.MethodGet, "/api/files/a%2Fb", parent)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "a%2Fb", id)
+}
+
+func TestRouteMetadata(t *testing.T) {
+	mux := NewServeMux()
+	mux.Use(func(c Context, next HandlerFunc) error {
+		if role, ok := c.RouteInfo().Get("requireRole").(string); ok && c.Request().Header.Get("X-Role") != role {
+			return ErrForbidden
+		}
+		return next(c)
+	})
+	h := func(c Context) error {
+		return c.NoContent(http.StatusOK)
+	}
+	r := mux.GET("/admin", h).Set("requireRole", "admin")
+	mux.GET("/public", h)
+
+	assert.Equal(t, "admin", r.Get("requireRole"))
+	assert.Nil(t, r.Get("missing"))
+
+	c, _ := request(http.MethodGet, "/admin", mux)
+	assert.Equal(t, http.StatusForbidden, c)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.Header.Set("X-Role", "admin")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	c, _ = request(http.MethodGet, "/public", mux)
+	assert.Equal(t, http.StatusOK, c)
+
+	c, _ = request(http.MethodGet, "/missing", mux)
+	assert.Equal(t, http.StatusNotFound, c)
+}
+
+func TestMuxSetNotFoundHandler(t *testing.T) {
+	mux := NewServeMux()
+	mux.SetNotFoundHandler(func(c Context) error {
+		return c.String(http.StatusNotFound, "custom not found")
+	})
+	mux.SetMethodNotAllowedHandler(func(c Context) error {
+		return c.String(http.StatusMethodNotAllowed, "custom method not allowed")
+	})
+	mux.NotFoundForPrefix("/api", func(c Context) error {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+	})
+	mux.GET("/users", func(c Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	g := mux.Group("/admin")
+	g.Use(func(c Context, next HandlerFunc) error {
+		return next(c)
+	})
+
+	c, b := request(http.MethodGet, "/missing", mux)
+	assert.Equal(t, http.StatusNotFound, c)
+	assert.Equal(t, "custom not found", b)
+
+	c, b = request(http.MethodPost, "/users", mux)
+	assert.Equal(t, http.StatusMethodNotAllowed, c)
+	assert.Equal(t, "custom method not allowed", b)
+
+	c, b = request(http.MethodGet, "/api/missing", mux)
+	assert.Equal(t, http.StatusNotFound, c)
+	assert.Equal(t, `{"error":"not found"}`, b)
+
+	c, b = request(http.MethodGet, "/admin/missing", mux)
+	assert.Equal(t, http.StatusNotFound, c)
+	assert.Equal(t, "custom not found", b)
+
+	// Files missing from static directories
+	mux.Static("/static", "testdata")
+	mux.Static("/api/static", "testdata")
+	mux.File("/api/missing.html", "testdata/missing.html")
+	c, b = request(http.MethodGet, "/static/missing.css", mux)
+	assert.Equal(t, http.StatusNotFound, c)
+	assert.Equal(t, "custom not found", b)
+	c, b = request(http.MethodGet, "/api/static/missing.css", mux)
+	assert.Equal(t, http.StatusNotFound, c)
+	assert.Equal(t, `{"error":"not found"}`, b)
+	c, b = request(http.MethodGet, "/api/missing.html", mux)
+	assert.Equal(t, http.StatusNotFound, c)
+	assert.Equal(t, `{"error":"not found"}`, b)
+}
@@ -0,0 +1,71 @@
+// Package protobuf adds Protocol Buffers request and response support to route.
+// It is kept separate from the core package, which has no external runtime
+// dependencies.
+package protobuf
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/goroute/route"
+	"google.golang.org/protobuf/proto"
+)
+
+var defaultBinder = &route.DefaultBinder{}
+
+// Binder is a route.Binder which unmarshals request bodies with the
+// `application/protobuf` Content-Type into proto.Message values and delegates
+// any other request to Fallback.
+//
+//	mux := route.NewServeMux(route.WithBinder(&protobuf.Binder{}))
+type Binder struct {
+	// Fallback binds requests without a protobuf body. Default
+	// route.DefaultBinder.
+	Fallback route.Binder
+}
+
+// Bind implements the `route.Binder#Bind` function.
+func (b *Binder) Bind(i interface{}, c route.Context) error {
+	if m, ok := i.(proto.Message); ok && isProtobuf(c.Request()) {
+		return Bind(c, m)
+	}
+	if b.Fallback != nil {
+		return b.Fallback.Bind(i, c)
+	}
+	return defaultBinder.Bind(i, c)
+}
+
+// Bind unmarshals the protobuf request body into m. It returns
+// route.ErrUnsupportedMediaType if the request has another Content-Type or no
+// body.
+func Bind(c route.Context, m proto.Message) error {
+	req := c.Request()
+	if !isProtobuf(req) || req.ContentLength == 0 {
+		return route.ErrUnsupportedMediaType
+	}
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return route.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	if len(b) == 0 {
+		return route.ErrUnsupportedMediaType
+	}
+	if err = proto.Unmarshal(b, m); err != nil {
+		return route.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	return nil
+}
+
+// Send sends m marshaled with proto.Marshal as a response with status code.
+func Send(c route.Context, code int, m proto.Message) error {
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return c.Blob(code, route.MIMEApplicationProtobuf, b)
+}
+
+func isProtobuf(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get(route.HeaderContentType), route.MIMEApplicationProtobuf)
+}
@@ -0,0 +1,78 @@
+package protobuf
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goroute/route"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestSend(t *testing.T) {
+	mux := route.NewServeMux()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := mux.NewContext(req, rec)
+
+	err := Send(c, http.StatusOK, wrapperspb.String("Jon Snow"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, route.MIMEApplicationProtobuf, rec.Header().Get(route.HeaderContentType))
+		m := new(wrapperspb.StringValue)
+		if assert.NoError(t, proto.Unmarshal(rec.Body.Bytes(), m)) {
+			assert.Equal(t, "Jon Snow", m.GetValue())
+		}
+	}
+}
+
+func TestBind(t *testing.T) {
+	body, _ := proto.Marshal(wrapperspb.String("Jon Snow"))
+	mux := route.NewServeMux(route.WithBinder(&Binder{}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set(route.HeaderContentType, route.MIMEApplicationProtobuf)
+	c := mux.NewContext(req, httptest.NewRecorder())
+	m := new(wrapperspb.StringValue)
+	if assert.NoError(t, c.Bind(m)) {
+		assert.Equal(t, "Jon Snow", m.GetValue())
+	}
+
+	// Invalid body
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("invalid"))
+	req.Header.Set(route.HeaderContentType, route.MIMEApplicationProtobuf)
+	c = mux.NewContext(req, httptest.NewRecorder())
+	err := c.Bind(new(wrapperspb.StringValue))
+	if assert.IsType(t, new(route.HTTPError), err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*route.HTTPError).Code)
+	}
+
+	// Empty body
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(route.HeaderContentType, route.MIMEApplicationProtobuf)
+	c = mux.NewContext(req, httptest.NewRecorder())
+	assert.Equal(t, route.ErrUnsupportedMediaType, Bind(c, new(wrapperspb.StringValue)))
+
+	// Wrong Content-Type
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set(route.HeaderContentType, route.MIMEApplicationJSON)
+	c = mux.NewContext(req, httptest.NewRecorder())
+	assert.Equal(t, route.ErrUnsupportedMediaType, Bind(c, new(wrapperspb.StringValue)))
+}
+
+func TestBinderFallback(t *testing.T) {
+	mux := route.NewServeMux(route.WithBinder(&Binder{}))
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"Jon Snow"}`))
+	req.Header.Set(route.HeaderContentType, route.MIMEApplicationJSON)
+	c := mux.NewContext(req, httptest.NewRecorder())
+	u := struct {
+		Name string `json:"name"`
+	}{}
+	if assert.NoError(t, c.Bind(&u)) {
+		assert.Equal(t, "Jon Snow", u.Name)
+	}
+}
@@ -0,0 +1,175 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+)
+
+type (
+	// ProxyTarget is an upstream server requests are proxied to.
+	ProxyTarget struct {
+		URL *url.URL
+
+		// Weight is the share of requests proxied to the target relative to
+		// the other targets. Default 1.
+		Weight int
+	}
+
+	// ProxyBalancer selects the target of proxied requests.
+	ProxyBalancer interface {
+		// Next returns the target to proxy the request to, or nil if none
+		// is available.
+		Next(c Context) *ProxyTarget
+
+		// Fail reports that proxying a request to target failed.
+		Fail(target *ProxyTarget)
+	}
+
+	// ProxyConfig defines the config for Proxy middleware.
+	ProxyConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Balancer selects the target of each request. Required.
+		Balancer ProxyBalancer
+	}
+
+	weightedRoundRobin struct {
+		mu       sync.Mutex
+		targets  []*weightedTarget
+		cooldown time.Duration
+		now      func() time.Time
+	}
+
+	weightedTarget struct {
+		*ProxyTarget
+		weight    int
+		current   int
+		downUntil time.Time
+	}
+
+	// proxyWriter records the error of a proxied request for the middleware.
+	proxyWriter struct {
+		*Response
+		err error
+	}
+)
+
+// DefaultProxyCooldown is how long the Proxy middleware skips a target after
+// proxying a request to it failed.
+const DefaultProxyCooldown = 10 * time.Second
+
+// Proxy returns a middleware which proxies requests to targets, balanced using
+// weighted round-robin. Targets which fail are skipped for
+// DefaultProxyCooldown.
+func Proxy(targets ...*ProxyTarget) MiddlewareFunc {
+	return ProxyWithConfig(ProxyConfig{
+		Balancer: NewWeightedRoundRobinBalancer(targets, DefaultProxyCooldown),
+	})
+}
+
+// ProxyWithConfig returns a Proxy middleware with config.
+// See: `Proxy()`.
+func ProxyWithConfig(config ProxyConfig) MiddlewareFunc {
+	if config.Balancer == nil {
+		panic("route: proxy middleware requires a balancer")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultSkipper
+	}
+	// The reverse proxy of each target, created on first use
+	var proxies sync.Map
+	proxyFor := func(target *ProxyTarget) *httputil.ReverseProxy {
+		if p, ok := proxies.Load(target); ok {
+			return p.(*httputil.ReverseProxy)
+		}
+		p := httputil.NewSingleHostReverseProxy(target.URL)
+		p.ErrorHandler = func(w http.ResponseWriter, r *http.Request, e error) {
+			config.Balancer.Fail(target)
+			w.(*proxyWriter).err = NewHTTPError(http.StatusBadGateway).SetInternal(e)
+		}
+		actual, _ := proxies.LoadOrStore(target, p)
+		return actual.(*httputil.ReverseProxy)
+	}
+
+	return func(c Context, next HandlerFunc) error {
+		if config.Skipper(c) {
+			return next(c)
+		}
+
+		target := config.Balancer.Next(c)
+		if target == nil {
+			return ErrBadGateway
+		}
+		w := &proxyWriter{Response: c.Response()}
+		proxyFor(target).ServeHTTP(w, c.Request())
+		return w.err
+	}
+}
+
+// NewWeightedRoundRobinBalancer returns a ProxyBalancer which distributes
+// requests across targets proportionally to their weights. Targets which
+// failed are skipped for cooldown, unless all targets failed.
+func NewWeightedRoundRobinBalancer(targets []*ProxyTarget, cooldown time.Duration) ProxyBalancer {
+	b := &weightedRoundRobin{cooldown: cooldown, now: time.Now}
+	for _, t := range targets {
+		weight := t.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		b.targets = append(b.targets, &weightedTarget{ProxyTarget: t, weight: weight})
+	}
+	return b
+}
+
+// Next implements the `ProxyBalancer#Next` function using the smooth weighted
+// round-robin algorithm, which interleaves the targets instead of sending
+// bursts of requests to the heaviest.
+func (b *weightedRoundRobin) Next(Context) *ProxyTarget {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	best := b.next(func(t *weightedTarget) bool { return now.After(t.downUntil) })
+	if best == nil {
+		best = b.next(func(*weightedTarget) bool { return true })
+	}
+	if best == nil {
+		return nil
+	}
+	return best.ProxyTarget
+}
+
+// next selects the next of the targets for which available returns true.
+func (b *weightedRoundRobin) next(available func(*weightedTarget) bool) *weightedTarget {
+	var best *weightedTarget
+	total := 0
+	for _, t := range b.targets {
+		if !available(t) {
+			continue
+		}
+		t.current += t.weight
+		total += t.weight
+		if best == nil || t.current > best.current {
+			best = t
+		}
+	}
+	if best != nil {
+		best.current -= total
+	}
+	return best
+}
+
+// Fail implements the `ProxyBalancer#Fail` function.
+func (b *weightedRoundRobin) Fail(target *ProxyTarget) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, t := range b.targets {
+		if t.ProxyTarget == target {
+			t.downUntil = b.now().Add(b.cooldown)
+			t.current = 0
+		}
+	}
+}
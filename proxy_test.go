@@ -0,0 +1,99 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newProxyTarget(t *testing.T, name string, weight int) (*ProxyTarget, *httptest.Server) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(name + r.URL.Path))
+	}))
+	u, err := url.Parse(s.URL)
+	assert.NoError(t, err)
+	return &ProxyTarget{URL: u, Weight: weight}, s
+}
+
+func TestProxy(t *testing.T) {
+	a, sa := newProxyTarget(t, "a", 3)
+	defer sa.Close()
+	b, sb := newProxyTarget(t, "b", 1)
+	defer sb.Close()
+
+	e := NewServeMux()
+	e.Use(Proxy(a, b))
+	counts := map[string]int{}
+	for i := 0; i < 8; i++ {
+		code, body := request(http.MethodGet, "/users", e)
+		assert.Equal(t, http.StatusOK, code)
+		counts[body]++
+	}
+	assert.Equal(t, map[string]int{"a/users": 6, "b/users": 2}, counts)
+}
+
+func TestProxyReusesReverseProxies(t *testing.T) {
+	a, sa := newProxyTarget(t, "a", 1)
+	defer sa.Close()
+	e := NewServeMux()
+	e.Use(Proxy(a))
+
+	_, body := request(http.MethodGet, "/", e)
+	assert.Equal(t, "a/", body)
+	// The reverse proxy of the target was created on first use
+	a.URL = &url.URL{Scheme: "http", Host: "127.0.0.1:1"}
+	_, body = request(http.MethodGet, "/", e)
+	assert.Equal(t, "a/", body)
+}
+
+func TestProxyFailedTarget(t *testing.T) {
+	a, sa := newProxyTarget(t, "a", 1)
+	defer sa.Close()
+	b, sb := newProxyTarget(t, "b", 1)
+	sb.Close()
+
+	e := NewServeMux()
+	e.Use(Proxy(a, b))
+	codes := map[int]int{}
+	for i := 0; i < 6; i++ {
+		code, _ := request(http.MethodGet, "/", e)
+		codes[code]++
+	}
+	// b is skipped after the first failure
+	assert.Equal(t, map[int]int{http.StatusOK: 5, http.StatusBadGateway: 1}, codes)
+}
+
+func TestWeightedRoundRobinBalancer(t *testing.T) {
+	a := &ProxyTarget{Weight: 2}
+	b := &ProxyTarget{}
+	balancer := NewWeightedRoundRobinBalancer([]*ProxyTarget{a, b}, time.Minute).(*weightedRoundRobin)
+	now := time.Now()
+	balancer.now = func() time.Time { return now }
+
+	// Smooth: a, b, a
+	assert.True(t, balancer.Next(nil) == a)
+	assert.True(t, balancer.Next(nil) == b)
+	assert.True(t, balancer.Next(nil) == a)
+
+	balancer.Fail(a)
+	assert.True(t, balancer.Next(nil) == b)
+	assert.True(t, balancer.Next(nil) == b)
+
+	// All failed
+	balancer.Fail(b)
+	assert.NotNil(t, balancer.Next(nil))
+
+	now = now.Add(time.Minute + time.Second)
+	seen := map[*ProxyTarget]int{}
+	for i := 0; i < 3; i++ {
+		seen[balancer.Next(nil)]++
+	}
+	assert.Equal(t, map[*ProxyTarget]int{a: 2, b: 1}, seen)
+
+	assert.Nil(t, NewWeightedRoundRobinBalancer(nil, time.Minute).Next(nil))
+	assert.Panics(t, func() { ProxyWithConfig(ProxyConfig{}) })
+}
@@ -0,0 +1,74 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// RecoverConfig defines the config for Recover middleware.
+type RecoverConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper Skipper
+
+	// ErrorFunc converts the value recovered from a panic into the error
+	// passed to the HTTP error handler, e.g. an *HTTPError with a status
+	// other than 500 for panics of a specific type. Default
+	// `DefaultRecoverErrorFunc`.
+	ErrorFunc func(recovered interface{}) error
+}
+
+// DefaultRecoverConfig is the default Recover middleware config.
+var DefaultRecoverConfig = RecoverConfig{
+	Skipper:   DefaultSkipper,
+	ErrorFunc: DefaultRecoverErrorFunc,
+}
+
+// Recover returns a middleware which recovers from panics in the handler chain
+// and returns them as errors, so they are handled by the HTTP error handler.
+func Recover() MiddlewareFunc {
+	return RecoverWithConfig(DefaultRecoverConfig)
+}
+
+// RecoverWithConfig returns a Recover middleware with config.
+// See: `Recover()`.
+//
+// Panics with http.ErrAbortHandler are not recovered, so that net/http aborts
+// the response as intended.
+func RecoverWithConfig(config RecoverConfig) MiddlewareFunc {
+	if config.Skipper == nil {
+		config.Skipper = DefaultRecoverConfig.Skipper
+	}
+	if config.ErrorFunc == nil {
+		config.ErrorFunc = DefaultRecoverConfig.ErrorFunc
+	}
+
+	return func(c Context, next HandlerFunc) (err error) {
+		if config.Skipper(c) {
+			return next(c)
+		}
+
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			if r == http.ErrAbortHandler {
+				panic(r)
+			}
+			err = config.ErrorFunc(r)
+		}()
+		return next(c)
+	}
+}
+
+// DefaultRecoverErrorFunc converts recovered into a 500 Internal Server Error
+// with the panic as internal error.
+func DefaultRecoverErrorFunc(recovered interface{}) error {
+	var err error
+	if e, ok := recovered.(error); ok {
+		err = fmt.Errorf("panic: %w", e)
+	} else {
+		err = fmt.Errorf("panic: %v", recovered)
+	}
+	return NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+}
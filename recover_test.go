@@ -0,0 +1,65 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type forbiddenPanic struct{}
+
+func TestRecover(t *testing.T) {
+	e := NewServeMux()
+	var logged error
+	e.ErrorLogger = func(c Context, err error) {
+		logged = err
+	}
+	e.Use(Recover())
+	e.GET("/", func(c Context) error {
+		panic("test")
+	})
+	e.GET("/error", func(c Context) error {
+		panic(errors.New("test"))
+	})
+
+	code, _ := request(http.MethodGet, "/", e)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.EqualError(t, logged, "panic: test")
+
+	code, _ = request(http.MethodGet, "/error", e)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.EqualError(t, logged, "panic: test")
+}
+
+func TestRecoverWithConfig(t *testing.T) {
+	e := NewServeMux()
+	e.Use(RecoverWithConfig(RecoverConfig{
+		ErrorFunc: func(recovered interface{}) error {
+			if _, ok := recovered.(forbiddenPanic); ok {
+				return ErrForbidden
+			}
+			return DefaultRecoverErrorFunc(recovered)
+		},
+	}))
+	e.GET("/forbidden", func(c Context) error {
+		panic(forbiddenPanic{})
+	})
+	e.GET("/", func(c Context) error {
+		panic("test")
+	})
+	e.GET("/abort", func(c Context) error {
+		panic(http.ErrAbortHandler)
+	})
+
+	code, _ := request(http.MethodGet, "/forbidden", e)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, _ = request(http.MethodGet, "/", e)
+	assert.Equal(t, http.StatusInternalServerError, code)
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		request(http.MethodGet, "/abort", e)
+	})
+}
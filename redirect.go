@@ -0,0 +1,106 @@
+package route
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RedirectConfig defines the config for the HTTPSRedirect, WWWRedirect and
+// NonWWWRedirect middleware.
+type RedirectConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper Skipper
+
+	// Code is the status code of the redirect. Use http.StatusPermanentRedirect
+	// to preserve the method of non-GET requests. Default
+	// http.StatusMovedPermanently.
+	Code int
+}
+
+// DefaultRedirectConfig is the default redirect middleware config.
+var DefaultRedirectConfig = RedirectConfig{
+	Skipper: DefaultSkipper,
+	Code:    http.StatusMovedPermanently,
+}
+
+// HTTPSRedirect returns a middleware which redirects plain HTTP requests to
+// the https:// equivalent URL, preserving path and query. Requests forwarded
+// by a TLS-terminating proxy are detected using the `X-Forwarded-Proto` or
+// `X-Url-Scheme` header. It should be registered with `Mux#Pre()`.
+func HTTPSRedirect() MiddlewareFunc {
+	return HTTPSRedirectWithConfig(DefaultRedirectConfig)
+}
+
+// HTTPSRedirectWithConfig returns a HTTPSRedirect middleware with config.
+// See: `HTTPSRedirect()`.
+func HTTPSRedirectWithConfig(config RedirectConfig) MiddlewareFunc {
+	return redirect(config, func(scheme, host string) (string, string, bool) {
+		return "https", host, scheme != "https"
+	})
+}
+
+// WWWRedirect returns a middleware which redirects requests to the www.
+// subdomain of the requested host, e.g. example.com to www.example.com. It
+// should be registered with `Mux#Pre()`.
+func WWWRedirect() MiddlewareFunc {
+	return WWWRedirectWithConfig(DefaultRedirectConfig)
+}
+
+// WWWRedirectWithConfig returns a WWWRedirect middleware with config.
+// See: `WWWRedirect()`.
+func WWWRedirectWithConfig(config RedirectConfig) MiddlewareFunc {
+	return redirect(config, func(scheme, host string) (string, string, bool) {
+		return scheme, "www." + host, !strings.HasPrefix(host, "www.")
+	})
+}
+
+// NonWWWRedirect returns a middleware which redirects requests for the www.
+// subdomain to the host without it, e.g. www.example.com to example.com. It
+// should be registered with `Mux#Pre()`.
+func NonWWWRedirect() MiddlewareFunc {
+	return NonWWWRedirectWithConfig(DefaultRedirectConfig)
+}
+
+// NonWWWRedirectWithConfig returns a NonWWWRedirect middleware with config.
+// See: `NonWWWRedirect()`.
+func NonWWWRedirectWithConfig(config RedirectConfig) MiddlewareFunc {
+	return redirect(config, func(scheme, host string) (string, string, bool) {
+		return scheme, strings.TrimPrefix(host, "www."), strings.HasPrefix(host, "www.")
+	})
+}
+
+// redirect returns a middleware which redirects requests for which target
+// returns true to the returned scheme and host.
+func redirect(config RedirectConfig, target func(scheme, host string) (string, string, bool)) MiddlewareFunc {
+	if config.Skipper == nil {
+		config.Skipper = DefaultRedirectConfig.Skipper
+	}
+	if config.Code == 0 {
+		config.Code = DefaultRedirectConfig.Code
+	}
+
+	return func(c Context, next HandlerFunc) error {
+		if config.Skipper(c) {
+			return next(c)
+		}
+		req := c.Request()
+		if scheme, host, ok := target(requestScheme(req), req.Host); ok {
+			return c.Redirect(config.Code, scheme+"://"+host+req.URL.RequestURI())
+		}
+		return next(c)
+	}
+}
+
+// requestScheme returns the scheme of the request as seen by the client.
+func requestScheme(r *http.Request) string {
+	if scheme := r.Header.Get(HeaderXForwardedProto); scheme != "" {
+		return strings.ToLower(strings.TrimSpace(strings.Split(scheme, ",")[0]))
+	}
+	if scheme := r.Header.Get(HeaderXUrlScheme); scheme != "" {
+		return strings.ToLower(scheme)
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
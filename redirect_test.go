@@ -0,0 +1,65 @@
+package route
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func redirectRequest(m MiddlewareFunc, target string, header http.Header, tls *tls.ConnectionState) *httptest.ResponseRecorder {
+	e := NewServeMux()
+	e.Pre(m)
+	e.GET("/*", func(c Context) error {
+		return c.String(http.StatusOK, "OK")
+	})
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.TLS = tls
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHTTPSRedirect(t *testing.T) {
+	rec := redirectRequest(HTTPSRedirect(), "http://example.com/users?id=1", nil, nil)
+	assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "https://example.com/users?id=1", rec.Header().Get(HeaderLocation))
+
+	rec = redirectRequest(HTTPSRedirect(), "https://example.com/users", nil, &tls.ConnectionState{})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Behind a TLS-terminating proxy
+	rec = redirectRequest(HTTPSRedirect(), "http://example.com/users", http.Header{HeaderXForwardedProto: {"https"}}, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = redirectRequest(HTTPSRedirect(), "http://example.com/users", http.Header{HeaderXUrlScheme: {"https"}}, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = redirectRequest(HTTPSRedirect(), "http://example.com/users", http.Header{HeaderXForwardedProto: {"http"}}, &tls.ConnectionState{})
+	assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+
+	rec = redirectRequest(HTTPSRedirectWithConfig(RedirectConfig{Code: http.StatusPermanentRedirect}), "http://example.com/", nil, nil)
+	assert.Equal(t, http.StatusPermanentRedirect, rec.Code)
+	assert.Equal(t, "https://example.com/", rec.Header().Get(HeaderLocation))
+}
+
+func TestWWWRedirect(t *testing.T) {
+	rec := redirectRequest(WWWRedirect(), "http://example.com/users?id=1", nil, nil)
+	assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "http://www.example.com/users?id=1", rec.Header().Get(HeaderLocation))
+
+	rec = redirectRequest(WWWRedirect(), "https://www.example.com/", nil, &tls.ConnectionState{})
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestNonWWWRedirect(t *testing.T) {
+	rec := redirectRequest(NonWWWRedirect(), "https://www.example.com/users", nil, &tls.ConnectionState{})
+	assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "https://example.com/users", rec.Header().Get(HeaderLocation))
+
+	rec = redirectRequest(NonWWWRedirect(), "http://example.com/", nil, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -2,6 +2,7 @@ package route
 
 import (
 	"bufio"
+	"io"
 	"net"
 	"net/http"
 )
@@ -10,13 +11,21 @@ type (
 	// Response wraps an http.ResponseWriter and implements its interface to be used
 	// by an HTTP handler to construct an HTTP response.
 	// See: https://golang.org/pkg/net/http/#ResponseWriter
+	//
+	// Response always implements the optional http.Flusher, http.Hijacker,
+	// http.Pusher, http.CloseNotifier and io.ReaderFrom interfaces. If the
+	// underlying writer doesn't, Flush does nothing, Hijack and Push return
+	// http.ErrNotSupported, CloseNotify returns a channel which never receives
+	// and ReadFrom copies through Write. Use Unwrap to check the capabilities
+	// of the underlying writer.
 	Response struct {
-		beforeFuncs []func()
-		afterFuncs  []func()
-		Writer      http.ResponseWriter
-		Status      int
-		Size        int64
-		Committed   bool
+		beforeFuncs   []func()
+		afterFuncs    []func()
+		pendingStatus int // Sent by an implicit WriteHeader instead of 200
+		Writer        http.ResponseWriter
+		Status        int
+		Size          int64
+		Committed     bool
 	}
 )
 
@@ -35,17 +44,28 @@ func (r *Response) Header() http.Header {
 	return r.Writer.Header()
 }
 
-// Before registers a function which is called just before the response is written.
+// Before registers a function which is called just before the response header
+// is written. Functions are called in the order they were registered.
 func (r *Response) Before(fn func()) {
 	r.beforeFuncs = append(r.beforeFuncs, fn)
 }
 
-// After registers a function which is called just after the response is written.
-// If the `Content-Length` is unknown, none of the after function is executed.
+// After registers a function which is called once the handler chain, including
+// the HTTP error handler, has completed and the response has been fully written,
+// e.g. to release resources or record the final Size. After functions run after
+// all Before functions, in the order they were registered, and also run if no
+// response was written.
 func (r *Response) After(fn func()) {
 	r.afterFuncs = append(r.afterFuncs, fn)
 }
 
+// runAfter calls the functions registered with After.
+func (r *Response) runAfter() {
+	for _, fn := range r.afterFuncs {
+		fn()
+	}
+}
+
 // WriteHeader sends an HTTP response header with status code. If WriteHeader is
 // not called explicitly, the first call to Write will trigger an implicit
 // WriteHeader(http.StatusOK). Thus explicit calls to WriteHeader are mainly
@@ -65,43 +85,94 @@ func (r *Response) WriteHeader(code int) {
 // Write writes the data to the connection as part of an HTTP reply.
 func (r *Response) Write(b []byte) (n int, err error) {
 	if !r.Committed {
-		r.WriteHeader(http.StatusOK)
+		r.WriteHeader(r.status())
 	}
 	n, err = r.Writer.Write(b)
 	r.Size += int64(n)
-	for _, fn := range r.afterFuncs {
-		fn()
+	return
+}
+
+// ReadFrom implements the io.ReaderFrom interface to allow the underlying
+// writer to optimize copying from src, e.g. using sendfile.
+func (r *Response) ReadFrom(src io.Reader) (n int64, err error) {
+	if !r.Committed {
+		r.WriteHeader(r.status())
+	}
+	if rf, ok := r.Writer.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = io.Copy(r.Writer, src)
 	}
+	r.Size += n
 	return
 }
 
 // Flush implements the http.Flusher interface to allow an HTTP handler to flush
-// buffered data to the client.
+// buffered data to the client. It does nothing if the underlying writer can't
+// be flushed.
 // See [http.Flusher](https://golang.org/pkg/net/http/#Flusher)
 func (r *Response) Flush() {
-	r.Writer.(http.Flusher).Flush()
+	if f, ok := r.Writer.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Push implements the http.Pusher interface to support HTTP/2 server push. It
+// returns http.ErrNotSupported if the underlying writer doesn't support it.
+// See [http.Pusher](https://golang.org/pkg/net/http/#Pusher)
+func (r *Response) Push(target string, opts *http.PushOptions) error {
+	p, ok := r.Writer.(http.Pusher)
+	if !ok {
+		return http.ErrNotSupported
+	}
+	return p.Push(target, opts)
 }
 
 // Hijack implements the http.Hijacker interface to allow an HTTP handler to
-// take over the connection.
+// take over the connection. It returns http.ErrNotSupported if the underlying
+// writer can't be hijacked.
 // See [http.Hijacker](https://golang.org/pkg/net/http/#Hijacker)
 func (r *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	return r.Writer.(http.Hijacker).Hijack()
+	h, ok := r.Writer.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	return h.Hijack()
 }
 
 // CloseNotify implements the http.CloseNotifier interface to allow detecting
 // when the underlying connection has gone away.
 // This mechanism can be used to cancel long operations on the server if the
 // client has disconnected before the response is ready.
+// It returns a channel which never receives if the underlying writer doesn't
+// support it.
 // See [http.CloseNotifier](https://golang.org/pkg/net/http/#CloseNotifier)
 func (r *Response) CloseNotify() <-chan bool {
-	return r.Writer.(http.CloseNotifier).CloseNotify()
+	if cn, ok := r.Writer.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return nil
+}
+
+// Unwrap returns the underlying http.ResponseWriter, e.g. to check whether it
+// implements an optional interface.
+func (r *Response) Unwrap() http.ResponseWriter {
+	return r.Writer
+}
+
+// status returns the status code sent by an implicit WriteHeader.
+func (r *Response) status() int {
+	if r.pendingStatus == 0 {
+		return http.StatusOK
+	}
+	return r.pendingStatus
 }
 
 func (r *Response) reset(w http.ResponseWriter) {
 	r.beforeFuncs = nil
 	r.afterFuncs = nil
 	r.Writer = w
+	r.pendingStatus = 0
 	r.Size = 0
 	r.Status = http.StatusOK
 	r.Committed = false
@@ -1,8 +1,11 @@
 package route
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -22,3 +25,159 @@ func TestResponse(t *testing.T) {
 	res.Write([]byte("test"))
 	assert.Equal(t, "mux", rec.Header().Get(HeaderServer))
 }
+
+func TestResponseHijackNotSupported(t *testing.T) {
+	res := &Response{Writer: httptest.NewRecorder()}
+	_, _, err := res.Hijack()
+	assert.Equal(t, http.ErrNotSupported, err)
+}
+
+type pushWriter struct {
+	*httptest.ResponseRecorder
+	pushed []string
+}
+
+func (w *pushWriter) Push(target string, _ *http.PushOptions) error {
+	w.pushed = append(w.pushed, target)
+	return nil
+}
+
+func TestResponseOptionalInterfaces(t *testing.T) {
+	var _ http.Flusher = new(Response)
+	var _ http.Pusher = new(Response)
+	var _ http.Hijacker = new(Response)
+
+	// Supported
+	w := &pushWriter{ResponseRecorder: httptest.NewRecorder()}
+	res := &Response{Writer: w}
+	assert.NoError(t, res.Push("/app.css", nil))
+	assert.Equal(t, []string{"/app.css"}, w.pushed)
+	res.Flush()
+	assert.True(t, w.Flushed)
+
+	// Not supported
+	res = &Response{Writer: struct{ http.ResponseWriter }{httptest.NewRecorder()}}
+	assert.Equal(t, http.ErrNotSupported, res.Push("/app.css", nil))
+	assert.NotPanics(t, res.Flush)
+	_, _, err := res.Hijack()
+	assert.Equal(t, http.ErrNotSupported, err)
+	assert.NotPanics(t, func() { res.CloseNotify() })
+}
+
+func TestResponseUnwrap(t *testing.T) {
+	rec := httptest.NewRecorder()
+	res := NewResponse(rec)
+	assert.True(t, res.Unwrap() == rec)
+}
+
+type readFromWriter struct {
+	*httptest.ResponseRecorder
+	readFrom bool
+}
+
+func (w *readFromWriter) ReadFrom(src io.Reader) (int64, error) {
+	w.readFrom = true
+	return io.Copy(w.ResponseRecorder, src)
+}
+
+func TestResponseReadFrom(t *testing.T) {
+	var _ io.ReaderFrom = new(Response)
+
+	w := &readFromWriter{ResponseRecorder: httptest.NewRecorder()}
+	res := &Response{Writer: w}
+	n, err := res.ReadFrom(strings.NewReader("test"))
+	if assert.NoError(t, err) {
+		assert.True(t, w.readFrom)
+		assert.EqualValues(t, 4, n)
+		assert.EqualValues(t, 4, res.Size)
+		assert.True(t, res.Committed)
+		assert.Equal(t, "test", w.Body.String())
+	}
+
+	// Not supported
+	rec := httptest.NewRecorder()
+	res = &Response{Writer: rec}
+	_, err = res.ReadFrom(strings.NewReader("test"))
+	if assert.NoError(t, err) {
+		assert.EqualValues(t, 4, res.Size)
+		assert.Equal(t, "test", rec.Body.String())
+	}
+}
+
+func TestResponseAfter(t *testing.T) {
+	e := NewServeMux()
+	var calls []string
+	var size int64
+	e.GET("/", func(c Context) error {
+		res := c.Response()
+		res.After(func() {
+			calls = append(calls, "after1")
+			size = res.Size
+		})
+		res.After(func() { calls = append(calls, "after2") })
+		res.Before(func() { calls = append(calls, "before") })
+		res.Write([]byte("te"))
+		res.Write([]byte("st"))
+		calls = append(calls, "handler")
+		return errors.New("error")
+	})
+
+	request(http.MethodGet, "/", e)
+	assert.Equal(t, []string{"before", "handler", "after1", "after2"}, calls)
+	assert.Equal(t, int64(4), size)
+}
+
+func TestResponseSizeAndStatus(t *testing.T) {
+	rec := httptest.NewRecorder()
+	res := NewResponse(rec)
+	res.WriteHeader(http.StatusCreated)
+	res.Write([]byte("test"))
+	res.Write([]byte("ing"))
+	assert.Equal(t, http.StatusCreated, res.Status)
+	assert.Equal(t, int64(7), res.Size)
+	assert.True(t, res.Committed)
+
+	// Written header can't be changed
+	res.WriteHeader(http.StatusBadRequest)
+	assert.Equal(t, http.StatusCreated, res.Status)
+
+	res.reset(httptest.NewRecorder())
+	assert.Equal(t, http.StatusOK, res.Status)
+	assert.Equal(t, int64(0), res.Size)
+	assert.False(t, res.Committed)
+}
+
+func TestResponseImplicitStatus(t *testing.T) {
+	rec := httptest.NewRecorder()
+	res := NewResponse(rec)
+	res.Status = http.StatusTeapot
+	res.Write([]byte("test"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, http.StatusOK, res.Status)
+}
+
+func TestResponseSizeAndStatusPooled(t *testing.T) {
+	e := NewServeMux()
+	var status int
+	var size int64
+	e.Use(func(c Context, next HandlerFunc) error {
+		err := next(c)
+		status, size = c.Response().Status, c.Response().Size
+		return err
+	})
+	e.GET("/long", func(c Context) error {
+		return c.String(http.StatusAccepted, "a long response")
+	})
+	e.GET("/empty", func(c Context) error {
+		return nil
+	})
+
+	request(http.MethodGet, "/long", e)
+	assert.Equal(t, http.StatusAccepted, status)
+	assert.Equal(t, int64(15), size)
+
+	// The pooled context starts from scratch
+	request(http.MethodGet, "/empty", e)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int64(0), size)
+}
@@ -0,0 +1,107 @@
+package route
+
+import (
+	stdcontext "context"
+	"log"
+	"net"
+	"net/http"
+	"os"
+)
+
+// ListenerFDEnv is the environment variable telling a process started by
+// `ExecHandoff` which file descriptor the inherited listener has.
+const ListenerFDEnv = "ROUTE_LISTENER_FD"
+
+type (
+	// Handoff passes the listener of a server to a new process on a graceful
+	// restart.
+	Handoff interface {
+		// Listen returns the listener inherited from the parent process, or a new
+		// one listening on addr.
+		Listen(addr string) (net.Listener, error)
+
+		// Restart starts a new process serving ln.
+		Restart(ln net.Listener) error
+	}
+
+	// ExecHandoff is a Handoff which re-executes the running binary with the same
+	// arguments, passing the listener as an extra file descriptor. Restarts are
+	// not supported on Windows.
+	ExecHandoff struct{}
+
+	// RestartConfig defines the config for Mux.StartWithRestartConfig.
+	RestartConfig struct {
+		StartConfig
+
+		// Handoff provides the listener and starts the new process on restart.
+		// Default ExecHandoff. StartConfig.Listener is ignored.
+		Handoff Handoff
+
+		// Signal triggers a restart when it receives a value. Default SIGHUP,
+		// none on Windows.
+		Signal <-chan os.Signal
+
+		// RestartError is called with the error of a failed restart, after
+		// which the server keeps serving. Default logs the error with the log
+		// package.
+		RestartError func(error)
+	}
+)
+
+// StartWithGracefulRestart starts an HTTP server listening on addr which
+// restarts on SIGHUP without dropping connections: a new process of the running
+// binary inherits the listener and the current one stops after serving its
+// pending requests.
+func (mux *Mux) StartWithGracefulRestart(addr string) error {
+	return mux.StartWithRestartConfig(RestartConfig{StartConfig: StartConfig{Address: addr}})
+}
+
+// StartWithRestartConfig starts an HTTP server with config which gracefully
+// restarts when config.Signal receives a value. It returns nil once the server
+// has been handed off, or the error which stopped it otherwise.
+// See: `StartWithGracefulRestart()`.
+func (mux *Mux) StartWithRestartConfig(config RestartConfig) error {
+	if config.Handoff == nil {
+		config.Handoff = ExecHandoff{}
+	}
+	if config.Signal == nil {
+		sig, stop := restartSignal()
+		defer stop()
+		config.Signal = sig
+	}
+	if config.RestartError == nil {
+		config.RestartError = func(err error) {
+			log.Printf("route: graceful restart failed: %v", err)
+		}
+	}
+
+	ln, err := config.Handoff.Listen(config.Address)
+	if err != nil {
+		return err
+	}
+	s := mux.newServer(config.StartConfig, ln)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.Serve(ln)
+	}()
+
+	for {
+		select {
+		case err := <-errc:
+			return err
+		case <-config.Signal:
+			if err := config.Handoff.Restart(ln); err != nil {
+				// Keep serving if the new process can't be started.
+				config.RestartError(err)
+				continue
+			}
+			if err := s.Shutdown(stdcontext.Background()); err != nil {
+				return err
+			}
+			if err := <-errc; err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		}
+	}
+}
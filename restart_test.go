@@ -0,0 +1,69 @@
+package route
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockHandoff struct {
+	ln        net.Listener
+	restarted chan net.Listener
+	err       error
+}
+
+func (h *mockHandoff) Listen(string) (net.Listener, error) {
+	return h.ln, nil
+}
+
+func (h *mockHandoff) Restart(ln net.Listener) error {
+	err := h.err
+	h.restarted <- ln
+	return err
+}
+
+func TestMuxStartWithRestartConfig(t *testing.T) {
+	e := NewServeMux()
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "OK")
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	handoff := &mockHandoff{ln: ln, restarted: make(chan net.Listener, 2), err: errors.New("failed")}
+	sig := make(chan os.Signal)
+	restartErr := make(chan error, 1)
+	done := make(chan error)
+	go func() {
+		done <- e.StartWithRestartConfig(RestartConfig{
+			Handoff:      handoff,
+			Signal:       sig,
+			RestartError: func(err error) { restartErr <- err },
+		})
+	}()
+
+	res, err := http.Get("http://" + ln.Addr().String() + "/")
+	if assert.NoError(t, err) {
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	// A failed restart keeps the server running
+	sig <- os.Interrupt
+	assert.Equal(t, ln, <-handoff.restarted)
+	assert.EqualError(t, <-restartErr, "failed")
+	res, err = http.Get("http://" + ln.Addr().String() + "/")
+	if assert.NoError(t, err) {
+		res.Body.Close()
+	}
+
+	handoff.err = nil
+	sig <- os.Interrupt
+	assert.Equal(t, ln, <-handoff.restarted)
+	assert.NoError(t, <-done)
+}
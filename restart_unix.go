@@ -0,0 +1,74 @@
+//go:build !windows
+
+package route
+
+import (
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"syscall"
+)
+
+type filer interface {
+	File() (*os.File, error)
+}
+
+// Listen implements the `Handoff#Listen` function.
+func (ExecHandoff) Listen(addr string) (net.Listener, error) {
+	fd := os.Getenv(ListenerFDEnv)
+	if fd == "" {
+		return net.Listen("tcp", addr)
+	}
+	n, err := strconv.Atoi(fd)
+	if err != nil {
+		return nil, errors.New("invalid " + ListenerFDEnv + ": " + fd)
+	}
+	f := os.NewFile(uintptr(n), "listener")
+	defer f.Close()
+	return net.FileListener(f)
+}
+
+// Restart implements the `Handoff#Restart` function.
+func (ExecHandoff) Restart(ln net.Listener) error {
+	l, ok := ln.(filer)
+	if !ok {
+		return errors.New("listener has no file descriptor")
+	}
+	f, err := l.File()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cmd, err := restartCommand(f)
+	if err != nil {
+		return err
+	}
+	return cmd.Start()
+}
+
+// restartCommand returns the command re-executing the running binary which
+// inherits f as its listener.
+func restartCommand(f *os.File) (*exec.Cmd, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// ExtraFiles start at file descriptor 3
+	cmd.ExtraFiles = []*os.File{f}
+	cmd.Env = append(os.Environ(), ListenerFDEnv+"=3")
+	return cmd, nil
+}
+
+// restartSignal returns a channel receiving SIGHUP and a function to stop it.
+func restartSignal() (<-chan os.Signal, func()) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	return sig, func() { signal.Stop(sig) }
+}
@@ -0,0 +1,68 @@
+//go:build !windows
+
+package route
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecHandoffListen(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ln.Close()
+	f, err := ln.(*net.TCPListener).File()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+
+	// Inherited listener
+	os.Setenv(ListenerFDEnv, strconv.Itoa(int(f.Fd())))
+	defer os.Unsetenv(ListenerFDEnv)
+	inherited, err := ExecHandoff{}.Listen("")
+	if assert.NoError(t, err) {
+		assert.Equal(t, ln.Addr().String(), inherited.Addr().String())
+		inherited.Close()
+	}
+
+	os.Setenv(ListenerFDEnv, "x")
+	_, err = ExecHandoff{}.Listen("")
+	assert.Error(t, err)
+
+	// New listener
+	os.Unsetenv(ListenerFDEnv)
+	fresh, err := ExecHandoff{}.Listen("127.0.0.1:0")
+	if assert.NoError(t, err) {
+		assert.NotEqual(t, ln.Addr().String(), fresh.Addr().String())
+		fresh.Close()
+	}
+}
+
+func TestRestartCommand(t *testing.T) {
+	f, err := os.Open(os.DevNull)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+	cmd, err := restartCommand(f)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []*os.File{f}, cmd.ExtraFiles)
+		assert.Equal(t, ListenerFDEnv+"=3", cmd.Env[len(cmd.Env)-1])
+		assert.Equal(t, os.Args[1:], cmd.Args[1:])
+	}
+}
+
+func TestExecHandoffRestartWithoutFile(t *testing.T) {
+	assert.Error(t, ExecHandoff{}.Restart(&mockListener{}))
+}
+
+type mockListener struct {
+	net.Listener
+}
@@ -0,0 +1,24 @@
+package route
+
+import (
+	"errors"
+	"net"
+	"os"
+)
+
+// Listen implements the `Handoff#Listen` function.
+func (ExecHandoff) Listen(addr string) (net.Listener, error) {
+	return net.Listen("tcp", addr)
+}
+
+// Restart implements the `Handoff#Restart` function. It is not supported on
+// Windows.
+func (ExecHandoff) Restart(ln net.Listener) error {
+	return errors.New("graceful restart is not supported on windows")
+}
+
+// restartSignal returns a channel which never receives, as Windows has no
+// SIGHUP.
+func restartSignal() (<-chan os.Signal, func()) {
+	return nil, func() {}
+}
@@ -1,14 +1,33 @@
 package route
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
 
 type (
 	// router is the registry of all registered routes for an `Mux` instance for
 	// request matching and URL path parameter parsing.
 	router struct {
-		tree   *node
-		routes map[string]*Route
-		mux    *Mux
+		tree     *node
+		routes   map[string]*Route
+		names    map[string]*Route // Routes by name, see Mux.Reverse
+		namesMu  sync.RWMutex
+		origins  map[string]string // Registered path to the path it was added as
+		notFound []prefixHandler   // Longest prefix first
+		// Named param constraints, e.g. "/users/:id{uuid}"
+		constraints map[string]string
+		mux         *Mux
+	}
+	// prefixHandler is a handler for the paths below prefix.
+	prefixHandler struct {
+		prefix  string
+		handler HandlerFunc
 	}
 	node struct {
 		kind          kind
@@ -19,6 +38,7 @@ type (
 		ppath         string
 		pnames        []string
 		methodHandler *methodHandler
+		regex         *regexp.Regexp // Param value constraint
 	}
 	kind          uint8
 	children      []*node
@@ -33,6 +53,17 @@ type (
 		propfind HandlerFunc
 		put      HandlerFunc
 		trace    HandlerFunc
+		queries  []*queryHandler
+		routes   map[string]*Route // Registered routes by method
+	}
+	// queryHandler is a handler which only matches requests carrying the
+	// given query parameters.
+	queryHandler struct {
+		method  string
+		query   url.Values
+		encoded string
+		handler HandlerFunc
+		route   *Route
 	}
 )
 
@@ -48,13 +79,91 @@ func newRouter(mux *Mux) *router {
 		tree: &node{
 			methodHandler: new(methodHandler),
 		},
-		routes: map[string]*Route{},
-		mux:    mux,
+		routes:  map[string]*Route{},
+		names:   map[string]*Route{},
+		origins: map[string]string{},
+		constraints: map[string]string{
+			"uuid": "[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}",
+			"slug": "[a-z0-9-]+",
+		},
+		mux: mux,
+	}
+}
+
+// clone returns a router for mux without routes, but with the constraints and
+// not found handlers of r.
+func (r *router) clone(mux *Mux) *router {
+	c := newRouter(mux)
+	for name, pattern := range r.constraints {
+		c.constraints[name] = pattern
+	}
+	c.notFound = append([]prefixHandler(nil), r.notFound...)
+	return c
+}
+
+// named returns the route named name, the first one sorted like
+// Mux.RoutesSorted if several share it, or nil.
+func (r *router) named(name string) *Route {
+	r.namesMu.RLock()
+	route := r.names[name]
+	r.namesMu.RUnlock()
+	if route != nil && route.Name == name && r.routes[route.Method+route.Path] == route {
+		return route
+	}
+	// Routes may have been renamed or replaced since they were added
+	r.namesMu.Lock()
+	defer r.namesMu.Unlock()
+	r.names = make(map[string]*Route, len(r.routes))
+	for _, route := range r.routes {
+		r.setName(route)
+	}
+	return r.names[name]
+}
+
+// addName registers route under its name for lookups by named.
+func (r *router) addName(route *Route) {
+	r.namesMu.Lock()
+	r.setName(route)
+	r.namesMu.Unlock()
+}
+
+func (r *router) setName(route *Route) {
+	if prev, ok := r.names[route.Name]; !ok || routeLess(route, prev) {
+		r.names[route.Name] = route
 	}
 }
 
 // add registers a new route for method and path with matching handler.
 func (r *router) add(method, path string, h HandlerFunc) {
+	r.addRoute(&Route{Method: method, Path: path}, h)
+}
+
+// addRoute registers h for route, which is made available to the context of
+// matching requests.
+//
+// A param may be followed by a regular expression in parentheses, e.g.
+// "/users/:id([0-9]+)", which its value has to match in full, or by the name of
+// a constraint in braces, e.g. "/users/:id{uuid}". Params with different
+// patterns at the same position, e.g. "/users/:id([0-9]+)" and
+// "/users/:name([a-z]+)", are sibling nodes in the tree. A request takes the
+// first one whose pattern matches the segment, in the order they were
+// registered, a param without a pattern last. It doesn't fall back to a later
+// sibling if the rest of the path doesn't match below the first one.
+//
+// A query string appended to the path, e.g. "/export?type=pdf", restricts the
+// route to requests carrying those query parameters. A parameter without a
+// value, e.g. "/export?type", only has to be present.
+//
+// Trailing params followed by '?', e.g. "/posts/:year/:month?", are optional.
+// The route is registered for the truncated paths as well, leaving the missing
+// params empty. It panics if one of these paths has been registered already by
+// another route.
+//
+// Routes which only differ in the names of their params, e.g. "/users/:id" and
+// "/users/:name", share a node in the tree, so it panics if they are both
+// registered, regardless of the method.
+func (r *router) addRoute(route *Route, h HandlerFunc) {
+	method, path := route.Method, route.Path
 	// Validate path
 	if path == "" {
 		panic("router: path cannot be empty")
@@ -62,38 +171,268 @@ func (r *router) add(method, path string, h HandlerFunc) {
 	if path[0] != '/' {
 		path = "/" + path
 	}
-	pnames := []string{} // Param names
-	ppath := path        // Pristine path
+	var query url.Values
+	rawQuery := ""
+	if i := queryIndex(path); i >= 0 {
+		var err error
+		if query, err = url.ParseQuery(path[i+1:]); err != nil {
+			panic("router: invalid query in path " + path)
+		}
+		path, rawQuery = path[:i], path[i:]
+	}
+
+	paths := expandOptional(path)
+	if paths == nil {
+		paths = []string{path}
+	}
+	for _, p := range paths {
+		key := method + " " + withoutParamNames(p) + rawQuery
+		if origin, ok := r.origins[key]; ok && origin != path {
+			panic(fmt.Sprintf("router: %s %s conflicts with already registered %s %s", method, path, method, origin))
+		}
+		r.origins[key] = path
+	}
+	for _, p := range paths {
+		r.addPath(method, p, query, h, route)
+	}
+}
+
+func (r *router) addPath(method, path string, query url.Values, h HandlerFunc, route *Route) {
+	pnames := []string{}      // Param names
+	res := []*regexp.Regexp{} // Param patterns
+	ppath := path             // Pristine path
 
 	for i, l := 0, len(path); i < l; i++ {
 		if path[i] == ':' {
 			j := i + 1
 
-			r.insert(method, path[:i], nil, skind, "", nil)
-			for ; i < l && path[i] != '/'; i++ {
+			r.insert(method, path[:i], nil, nil, skind, "", nil, nil, res)
+			for ; i < l && path[i] != '/' && path[i] != '(' && path[i] != '{'; i++ {
 			}
+			name := path[j:i]
 
-			pnames = append(pnames, path[j:i])
+			var re *regexp.Regexp
+			if i < l && path[i] == '(' {
+				k := patternEnd(path, i)
+				if k < 0 {
+					panic(fmt.Sprintf("router: unterminated pattern for param %q in path %s", name, ppath))
+				}
+				re = compilePattern(name, path[i+1:k-1])
+				i = k
+			} else if i < l && path[i] == '{' {
+				k := strings.IndexByte(path[i:], '}')
+				if k < 0 {
+					panic(fmt.Sprintf("router: unterminated constraint for param %q in path %s", name, ppath))
+				}
+				constraint := path[i+1 : i+k]
+				pattern, ok := r.constraints[constraint]
+				if !ok {
+					panic(fmt.Sprintf("router: unknown constraint %q for param %q", constraint, name))
+				}
+				re = compilePattern(name, pattern)
+				i += k + 1
+			}
+
+			pnames = append(pnames, name)
+			res = append(res, re)
 			path = path[:j] + path[i:]
 			i, l = j, len(path)
 
 			if i == l {
-				r.insert(method, path[:i], h, pkind, ppath, pnames)
+				r.insert(method, path[:i], h, route, pkind, ppath, pnames, query, res)
 				return
 			}
-			r.insert(method, path[:i], nil, pkind, "", nil)
+			r.insert(method, path[:i], nil, nil, pkind, "", nil, nil, res)
 		} else if path[i] == '*' {
-			r.insert(method, path[:i], nil, skind, "", nil)
+			r.insert(method, path[:i], nil, nil, skind, "", nil, nil, res)
 			pnames = append(pnames, "*")
-			r.insert(method, path[:i+1], h, akind, ppath, pnames)
+			r.insert(method, path[:i+1], h, route, akind, ppath, pnames, query, res)
 			return
 		}
 	}
 
-	r.insert(method, path, h, skind, ppath, pnames)
+	r.insert(method, path, h, route, skind, ppath, pnames, query, res)
+}
+
+// expandOptional returns the paths a route with optional params is registered
+// for, shortest first, with the '?' markers removed. It returns nil if path has
+// no optional params.
+func expandOptional(path string) []string {
+	var (
+		paths    []string
+		full     = make([]byte, 0, len(path))
+		start    = 0 // Start of the current segment in full
+		optional = false
+		depth    = 0
+	)
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case c == '\\' && i+1 < len(path):
+			full = append(full, c, path[i+1])
+			i++
+			continue
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == '/' && depth == 0:
+			if paths != nil && !optional {
+				panic("router: optional params must be at the end of path " + path)
+			}
+			start, optional = len(full), false
+		case c == '?' && depth == 0:
+			if start+1 >= len(full) || full[start+1] != ':' {
+				panic("router: only params can be optional in path " + path)
+			}
+			if start == 0 {
+				paths = append(paths, "/")
+			} else {
+				paths = append(paths, string(full[:start]))
+			}
+			optional = true
+			continue
+		}
+		full = append(full, c)
+	}
+	if paths == nil {
+		return nil
+	}
+	if !optional {
+		panic("router: optional params must be at the end of path " + path)
+	}
+	return append(paths, string(full))
+}
+
+// reversePath returns path with its params replaced by values in order.
+func reversePath(path string, values []interface{}) string {
+	b := make([]byte, 0, len(path))
+	n := 0
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case ':':
+			j := i + 1
+			for ; j < len(path) && path[j] != '/' && path[j] != '(' && path[j] != '{' && path[j] != '?'; j++ {
+			}
+			if j < len(path) && path[j] == '(' {
+				if j = patternEnd(path, j); j < 0 {
+					j = len(path)
+				}
+			} else if j < len(path) && path[j] == '{' {
+				if k := strings.IndexByte(path[j:], '}'); k >= 0 {
+					j += k + 1
+				}
+			}
+			optional := j < len(path) && path[j] == '?' && (j+1 == len(path) || path[j+1] == '/')
+			if optional {
+				j++
+			}
+			if n < len(values) {
+				b = append(b, url.PathEscape(fmt.Sprint(values[n]))...)
+				n++
+			} else if optional && len(b) > 1 {
+				b = b[:len(b)-1] // Drop the slash preceding the missing param
+			}
+			i = j - 1
+		case '*':
+			if n < len(values) {
+				b = append(b, fmt.Sprint(values[n])...)
+				n++
+			}
+		default:
+			b = append(b, path[i])
+		}
+	}
+	return string(b)
+}
+
+// queryIndex returns the index of the '?' starting the query string of path, or
+// -1 if there is none. A '?' inside a param pattern or ending a segment doesn't
+// start a query string.
+func queryIndex(path string) int {
+	depth := 0
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '?':
+			if depth == 0 && i+1 < len(path) && path[i+1] != '/' {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// withoutParamNames returns path without the names of its params, e.g.
+// "/users/:([0-9]+)" for "/users/:id([0-9]+)", as paths which only differ in
+// them match the same requests.
+func withoutParamNames(path string) string {
+	b := make([]byte, 0, len(path))
+	for i := 0; i < len(path); i++ {
+		b = append(b, path[i])
+		if path[i] != ':' {
+			continue
+		}
+		j := i + 1
+		for j < len(path) && path[j] != '/' && path[j] != '(' && path[j] != '{' {
+			j++
+		}
+		// Copy the pattern, which may contain colons, as is
+		k := len(path)
+		if j < len(path) && path[j] == '(' {
+			if e := patternEnd(path, j); e >= 0 {
+				k = e
+			}
+		} else if j < len(path) && path[j] == '{' {
+			if e := strings.IndexByte(path[j:], '}'); e >= 0 {
+				k = j + e + 1
+			}
+		} else {
+			k = j
+		}
+		b = append(b, path[j:k]...)
+		i = k - 1
+	}
+	return string(b)
+}
+
+// patternEnd returns the index following the parenthesis closing the one at
+// index i, or -1 if it isn't closed.
+func patternEnd(path string, i int) int {
+	depth := 0
+	for ; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
 }
 
-func (r *router) insert(method, path string, h HandlerFunc, t kind, ppath string, pnames []string) {
+func compilePattern(name, pattern string) *regexp.Regexp {
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		panic(fmt.Sprintf("router: invalid pattern for param %q: %v", name, err))
+	}
+	return re
+}
+
+// insert adds the nodes of path to the tree. Params are taken from the
+// child with the pattern in res, so params with different patterns at the
+// same position are siblings.
+func (r *router) insert(method, path string, h HandlerFunc, route *Route, t kind, ppath string, pnames []string, query url.Values, res []*regexp.Regexp) *node {
 	// Adjust max param
 	l := len(pnames)
 	if *r.mux.maxParam < l {
@@ -125,15 +464,17 @@ func (r *router) insert(method, path string, h HandlerFunc, t kind, ppath string
 			cn.prefix = search
 			if h != nil {
 				cn.kind = t
-				cn.addHandler(method, h)
+				cn.addHandler(method, h, route, query)
 				cn.ppath = ppath
 				cn.pnames = pnames
 			}
 		} else if l < pl {
 			// Split node
 			n := newNode(cn.kind, cn.prefix[l:], cn, cn.children, cn.methodHandler, cn.ppath, cn.pnames)
+			n.regex = cn.regex
 
 			// Reset parent node
+			cn.regex = nil
 			cn.kind = skind
 			cn.label = cn.prefix[0]
 			cn.prefix = cn.prefix[:l]
@@ -147,18 +488,26 @@ func (r *router) insert(method, path string, h HandlerFunc, t kind, ppath string
 			if l == sl {
 				// At parent node
 				cn.kind = t
-				cn.addHandler(method, h)
+				cn.addHandler(method, h, route, query)
 				cn.ppath = ppath
 				cn.pnames = pnames
 			} else {
 				// Create child node
 				n = newNode(t, search[l:], cn, nil, new(methodHandler), ppath, pnames)
-				n.addHandler(method, h)
+				n.addHandler(method, h, route, query)
 				cn.addChild(n)
+				return n
 			}
 		} else if l < sl {
 			search = search[l:]
-			c := cn.findChildWithLabel(search[0])
+			var c *node
+			var re *regexp.Regexp
+			if search[0] == ':' {
+				re = res[strings.Count(path[:len(path)-len(search)], ":")]
+				c = cn.findParamChild(re)
+			} else {
+				c = cn.findChildWithLabel(search[0])
+			}
 			if c != nil {
 				// Go deeper
 				cn = c
@@ -166,22 +515,40 @@ func (r *router) insert(method, path string, h HandlerFunc, t kind, ppath string
 			}
 			// Create child node
 			n := newNode(t, search, cn, nil, new(methodHandler), ppath, pnames)
-			n.addHandler(method, h)
+			n.regex = re
+			n.addHandler(method, h, route, query)
 			cn.addChild(n)
+			return n
 		} else {
 			// Node already exists
 			if h != nil {
-				cn.addHandler(method, h)
+				if len(cn.pnames) > 0 && !equalNames(cn.pnames, pnames) {
+					panic(fmt.Sprintf("router: param names of path %s conflict with path %s registered before", ppath, cn.ppath))
+				}
+				cn.addHandler(method, h, route, query)
 				cn.ppath = ppath
 				if len(cn.pnames) == 0 { // Issue #729
 					cn.pnames = pnames
 				}
 			}
 		}
-		return
+		return cn
 	}
 }
 
+// equalNames reports whether the param names a and b are equal.
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func newNode(t kind, pre string, p *node, c children, mh *methodHandler, ppath string, pnames []string) *node {
 	return &node{
 		kind:          t,
@@ -195,7 +562,24 @@ func newNode(t kind, pre string, p *node, c children, mh *methodHandler, ppath s
 	}
 }
 
+func patternOf(re *regexp.Regexp) string {
+	if re == nil {
+		return ""
+	}
+	return re.String()
+}
+
 func (n *node) addChild(c *node) {
+	if c.kind == pkind && c.regex != nil {
+		// Params with a pattern are tried before the one without
+		for i, s := range n.children {
+			if s.kind == pkind && s.regex == nil {
+				n.children = append(n.children[:i+1], n.children[i:]...)
+				n.children[i] = c
+				return
+			}
+		}
+	}
 	n.children = append(n.children, c)
 }
 
@@ -217,6 +601,34 @@ func (n *node) findChildWithLabel(l byte) *node {
 	return nil
 }
 
+func (n *node) findParamChild(re *regexp.Regexp) *node {
+	for _, c := range n.children {
+		if c.kind == pkind && patternOf(c.regex) == patternOf(re) {
+			return c
+		}
+	}
+	return nil
+}
+
+// matchParam returns the first param child of n whose pattern matches the
+// segment at the start of search, along with the length of the segment.
+func (n *node) matchParam(search string) (*node, int) {
+	i := strings.IndexByte(search, '/')
+	if i < 0 {
+		i = len(search)
+	}
+	// Params don't match the empty segments of repeated slashes
+	if i == 0 {
+		return nil, 0
+	}
+	for _, c := range n.children {
+		if c.kind == pkind && (c.regex == nil || c.regex.MatchString(search[:i])) {
+			return c, i
+		}
+	}
+	return nil, 0
+}
+
 func (n *node) findChildByKind(t kind) *node {
 	for _, c := range n.children {
 		if c.kind == t {
@@ -226,7 +638,17 @@ func (n *node) findChildByKind(t kind) *node {
 	return nil
 }
 
-func (n *node) addHandler(method string, h HandlerFunc) {
+func (n *node) addHandler(method string, h HandlerFunc, route *Route, query url.Values) {
+	if query != nil {
+		n.addQueryHandler(method, h, route, query)
+		return
+	}
+	if h != nil {
+		if n.methodHandler.routes == nil {
+			n.methodHandler.routes = map[string]*Route{}
+		}
+		n.methodHandler.routes[method] = route
+	}
 	switch method {
 	case http.MethodConnect:
 		n.methodHandler.connect = h
@@ -251,6 +673,60 @@ func (n *node) addHandler(method string, h HandlerFunc) {
 	}
 }
 
+func (n *node) addQueryHandler(method string, h HandlerFunc, route *Route, query url.Values) {
+	if h == nil {
+		return
+	}
+	qh := &queryHandler{method: method, query: query, encoded: query.Encode(), handler: h, route: route}
+	for i, q := range n.methodHandler.queries {
+		if q.method == method && q.encoded == qh.encoded {
+			n.methodHandler.queries[i] = qh
+			return
+		}
+	}
+	n.methodHandler.queries = append(n.methodHandler.queries, qh)
+}
+
+// findQueryHandler returns the first handler registered for method whose query
+// parameters are all present in the request.
+func (n *node) findQueryHandler(method string, c *context) *queryHandler {
+	if len(n.methodHandler.queries) == 0 || c.request == nil {
+		return nil
+	}
+	params := c.QueryParams()
+	for _, q := range n.methodHandler.queries {
+		if q.method == method && q.matches(params) {
+			return q
+		}
+	}
+	return nil
+}
+
+func (q *queryHandler) matches(params url.Values) bool {
+	for name, values := range q.query {
+		actual, ok := params[name]
+		if !ok {
+			return false
+		}
+		for _, v := range values {
+			if v == "" {
+				continue
+			}
+			found := false
+			for _, a := range actual {
+				if a == v {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func (n *node) findHandler(method string) HandlerFunc {
 	switch method {
 	case http.MethodConnect:
@@ -278,13 +754,46 @@ func (n *node) findHandler(method string) HandlerFunc {
 	}
 }
 
-func (n *node) checkMethodNotAllowed() HandlerFunc {
+func (n *node) checkMethodNotAllowed(notFound, methodNotAllowed HandlerFunc) HandlerFunc {
 	for _, m := range methods {
 		if h := n.findHandler(m); h != nil {
-			return MethodNotAllowedHandler
+			return methodNotAllowed
 		}
 	}
-	return NotFoundHandler
+	return notFound
+}
+
+// addNotFound registers h for the requests to paths below prefix which don't
+// match any route.
+func (r *router) addNotFound(prefix string, h HandlerFunc) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	for i, ph := range r.notFound {
+		if ph.prefix == prefix {
+			r.notFound[i].handler = h
+			return
+		}
+	}
+	r.notFound = append(r.notFound, prefixHandler{prefix, h})
+	sort.SliceStable(r.notFound, func(i, j int) bool {
+		return len(r.notFound[i].prefix) > len(r.notFound[j].prefix)
+	})
+}
+
+// notFoundHandler returns the handler for requests to path which don't match
+// any route.
+func (r *router) notFoundHandler(path string) HandlerFunc {
+	for _, ph := range r.notFound {
+		if path == ph.prefix || strings.HasPrefix(path, ph.prefix+"/") {
+			return ph.handler
+		}
+	}
+	return r.mux.notFound()
+}
+
+// notFound sends the response of the not found handler for the request path,
+// e.g. for files missing from a static directory.
+func notFound(c Context) error {
+	return c.Mux().router.notFoundHandler(getPath(c.Request()))(c)
 }
 
 // Find lookup a handler registered for method and path. It also parses URL for path
@@ -297,6 +806,19 @@ func (n *node) checkMethodNotAllowed() HandlerFunc {
 // - Return it `Mux#ReleaseContext()`.
 func (r *router) find(method, path string, c Context) {
 	ctx := c.(*context)
+	notFound := r.mux.notFound()
+	if len(r.notFound) > 0 {
+		notFound = r.notFoundHandler(path)
+	}
+	ctx.handler = notFound
+	if strings.Contains(path, "//") {
+		if r.mux.MergeSlashes {
+			path = mergeSlashes(path)
+		} else if r.mux.RejectRepeatedSlashes {
+			ctx.path = path
+			return // Not found
+		}
+	}
 	ctx.path = path
 	cn := r.tree // Current node as root
 
@@ -365,7 +887,7 @@ func (r *router) find(method, path string, c Context) {
 
 		// Param node
 	Param:
-		if child = cn.findChildByKind(pkind); child != nil {
+		if child, i := cn.matchParam(search); child != nil {
 			// Issue #378
 			if len(pvalues) == n {
 				continue
@@ -379,9 +901,6 @@ func (r *router) find(method, path string, c Context) {
 			}
 
 			cn = child
-			i, l := 0, len(search)
-			for ; i < l && search[i] != '/'; i++ {
-			}
 			pvalues[n] = search[:i]
 			n++
 			search = search[i:]
@@ -409,12 +928,17 @@ func (r *router) find(method, path string, c Context) {
 	}
 
 	ctx.handler = cn.findHandler(method)
+	ctx.route = cn.methodHandler.routes[method]
+	if q := cn.findQueryHandler(method, ctx); q != nil {
+		ctx.handler = q.handler
+		ctx.route = q.route
+	}
 	ctx.path = cn.ppath
 	ctx.pnames = cn.pnames
 
 	// NOTE: Slow zone...
 	if ctx.handler == nil {
-		ctx.handler = cn.checkMethodNotAllowed()
+		ctx.handler = cn.checkMethodNotAllowed(notFound, r.mux.methodNotAllowed())
 
 		// Dig further for any, might have an empty value for *, e.g.
 		// serving a directory. Issue #207.
@@ -423,8 +947,9 @@ func (r *router) find(method, path string, c Context) {
 		}
 		if h := cn.findHandler(method); h != nil {
 			ctx.handler = h
+			ctx.route = cn.methodHandler.routes[method]
 		} else {
-			ctx.handler = cn.checkMethodNotAllowed()
+			ctx.handler = cn.checkMethodNotAllowed(notFound, r.mux.methodNotAllowed())
 		}
 		ctx.path = cn.ppath
 		ctx.pnames = cn.pnames
@@ -433,3 +958,15 @@ func (r *router) find(method, path string, c Context) {
 
 	return
 }
+
+// mergeSlashes replaces every run of slashes in path by a single one.
+func mergeSlashes(path string) string {
+	b := make([]byte, 0, len(path))
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && i > 0 && path[i-1] == '/' {
+			continue
+		}
+		b = append(b, path[i])
+	}
+	return string(b)
+}
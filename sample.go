@@ -0,0 +1,57 @@
+package route
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// SampleConfig defines the config for Sample middleware.
+type SampleConfig struct {
+	// Fraction of requests, between 0 and 1, the middleware is applied to.
+	Fraction float64
+
+	// Middleware is applied to the sampled requests.
+	Middleware MiddlewareFunc
+
+	// Key returns a value identifying the request, e.g. a user ID. Requests with
+	// the same key are consistently either sampled or not. Requests are sampled
+	// randomly when Key is nil or returns an empty string.
+	Key func(Context) string
+}
+
+// Sample returns a middleware which applies mw to a random fraction of requests,
+// e.g. for canary instrumentation or dark launches.
+func Sample(fraction float64, mw MiddlewareFunc) MiddlewareFunc {
+	return SampleWithConfig(SampleConfig{Fraction: fraction, Middleware: mw})
+}
+
+// SampleWithConfig returns a Sample middleware with config. It panics if
+// config.Middleware is nil or config.Fraction isn't between 0 and 1.
+// See: `Sample()`.
+func SampleWithConfig(config SampleConfig) MiddlewareFunc {
+	if config.Middleware == nil {
+		panic("route: sample middleware requires a middleware")
+	}
+	if !(config.Fraction >= 0 && config.Fraction <= 1) {
+		panic(fmt.Sprintf("route: sample fraction %v not between 0 and 1", config.Fraction))
+	}
+	return func(c Context, next HandlerFunc) error {
+		if config.sampled(c) {
+			return config.Middleware(c, next)
+		}
+		return next(c)
+	}
+}
+
+func (config SampleConfig) sampled(c Context) bool {
+	if config.Key != nil {
+		if key := config.Key(c); key != "" {
+			sum := sha1.Sum([]byte(key))
+			return float64(binary.BigEndian.Uint64(sum[:]))/math.MaxUint64 < config.Fraction
+		}
+	}
+	return rand.Float64() < config.Fraction
+}
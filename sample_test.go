@@ -0,0 +1,73 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSample(t *testing.T) {
+	e := NewServeMux()
+	sampled := 0
+	mw := Sample(0.3, func(c Context, next HandlerFunc) error {
+		sampled++
+		return next(c)
+	})
+	h := func(c Context) error {
+		return c.NoContent(http.StatusOK)
+	}
+
+	n := 10000
+	for i := 0; i < n; i++ {
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+		assert.NoError(t, mw(c, h))
+	}
+	assert.InDelta(t, 0.3, float64(sampled)/float64(n), 0.05)
+}
+
+func TestSampleKey(t *testing.T) {
+	e := NewServeMux()
+	sampled := map[string]int{}
+	mw := SampleWithConfig(SampleConfig{
+		Fraction: 0.5,
+		Middleware: func(c Context, next HandlerFunc) error {
+			sampled[c.QueryParam("user")]++
+			return next(c)
+		},
+		Key: func(c Context) string {
+			return c.QueryParam("user")
+		},
+	})
+	h := func(c Context) error {
+		return c.NoContent(http.StatusOK)
+	}
+
+	for i := 0; i < 3; i++ {
+		for u := 0; u < 100; u++ {
+			req := httptest.NewRequest(http.MethodGet, "/?user="+strconv.Itoa(u), nil)
+			c := e.NewContext(req, httptest.NewRecorder())
+			assert.NoError(t, mw(c, h))
+		}
+	}
+
+	// Sticky: a user is either always or never sampled
+	for _, n := range sampled {
+		assert.Equal(t, 3, n)
+	}
+	assert.InDelta(t, 50, len(sampled), 20)
+}
+
+func TestSampleInvalidConfig(t *testing.T) {
+	mw := func(c Context, next HandlerFunc) error { return next(c) }
+	assert.PanicsWithValue(t, "route: sample middleware requires a middleware", func() {
+		Sample(0.5, nil)
+	})
+	assert.PanicsWithValue(t, "route: sample fraction 1.5 not between 0 and 1", func() {
+		Sample(1.5, mw)
+	})
+	assert.Panics(t, func() { Sample(-0.1, mw) })
+	assert.NotPanics(t, func() { Sample(0, mw) })
+}
@@ -0,0 +1,90 @@
+package route
+
+import (
+	stdcontext "context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// StartConfig defines the config for the HTTP server started by
+// Mux.StartWithConfig.
+type StartConfig struct {
+	// Address is the TCP address to listen on, e.g. ":8080".
+	Address string
+
+	// Listener is served instead of listening on Address when set.
+	Listener net.Listener
+
+	// ConnState is called when a client connection changes state, see
+	// http.Server.ConnState. It can be used to track connection metrics.
+	ConnState func(net.Conn, http.ConnState)
+
+	// ReadTimeout, WriteTimeout and IdleTimeout are passed to http.Server.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+}
+
+// Start starts an HTTP server listening on address.
+func (mux *Mux) Start(address string) error {
+	return mux.StartWithConfig(StartConfig{Address: address})
+}
+
+// StartWithConfig starts an HTTP server with config. It blocks until the
+// server stops and returns the error which stopped it.
+func (mux *Mux) StartWithConfig(config StartConfig) error {
+	ln := config.Listener
+	if ln == nil {
+		var err error
+		if ln, err = net.Listen("tcp", config.Address); err != nil {
+			return err
+		}
+	}
+	return mux.newServer(config, ln).Serve(ln)
+}
+
+// Shutdown gracefully shuts down the server started by mux, waiting for active
+// requests to complete until ctx is done, see http.Server.Shutdown. Endpoints
+// registered with `Health()` report the mux as unavailable from then on.
+func (mux *Mux) Shutdown(ctx stdcontext.Context) error {
+	atomic.StoreInt32(&mux.draining, 1)
+	s, _ := mux.server.Load().(*http.Server)
+	if s == nil {
+		return nil
+	}
+	return s.Shutdown(ctx)
+}
+
+// Health registers a GET readiness endpoint for path, e.g. for load balancers
+// or Kubernetes probes. It responds with payload as JSON, {"status":"ok"} by
+// default, while the mux is serving and with `ErrServiceUnavailable` once
+// `Shutdown()` was initiated, so that traffic is drained.
+func (mux *Mux) Health(path string, payload ...interface{}) *Route {
+	var body interface{} = map[string]string{"status": "ok"}
+	if len(payload) > 0 {
+		body = payload[0]
+	}
+	return mux.GET(path, func(c Context) error {
+		if atomic.LoadInt32(&mux.draining) == 1 {
+			return ErrServiceUnavailable
+		}
+		return c.JSON(http.StatusOK, body)
+	})
+}
+
+// newServer returns the server for config serving ln and registers it as the
+// server of mux.
+func (mux *Mux) newServer(config StartConfig, ln net.Listener) *http.Server {
+	s := &http.Server{
+		Addr:         ln.Addr().String(),
+		Handler:      mux,
+		ConnState:    config.ConnState,
+		ReadTimeout:  config.ReadTimeout,
+		WriteTimeout: config.WriteTimeout,
+		IdleTimeout:  config.IdleTimeout,
+	}
+	mux.server.Store(s)
+	return s
+}
@@ -0,0 +1,88 @@
+package route
+
+import (
+	stdcontext "context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxStartConnState(t *testing.T) {
+	e := NewServeMux()
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "OK")
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ln.Close()
+
+	states := make(chan http.ConnState, 16)
+	go e.StartWithConfig(StartConfig{
+		Listener: ln,
+		ConnState: func(_ net.Conn, state http.ConnState) {
+			select {
+			case states <- state:
+			default:
+			}
+		},
+	})
+
+	res, err := http.Get("http://" + ln.Addr().String() + "/")
+	if assert.NoError(t, err) {
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+	assert.Equal(t, http.StateNew, <-states)
+	assert.Equal(t, http.StateActive, <-states)
+}
+
+func TestMuxStartInvalidAddress(t *testing.T) {
+	e := NewServeMux()
+	assert.Error(t, e.Start("invalid address"))
+}
+
+func TestMuxShutdown(t *testing.T) {
+	e := NewServeMux()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	done := make(chan error)
+	go func() {
+		done <- e.StartWithConfig(StartConfig{Listener: ln})
+	}()
+	for {
+		if s, _ := e.server.Load().(*http.Server); s != nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.NoError(t, e.Shutdown(stdcontext.Background()))
+	assert.Equal(t, http.ErrServerClosed, <-done)
+}
+
+func TestMuxHealth(t *testing.T) {
+	e := NewServeMux()
+	e.Health("/healthz")
+	e.Health("/ready", map[string]interface{}{"status": "ready", "version": Version})
+
+	code, body := request(http.MethodGet, "/healthz", e)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `{"status":"ok"}`, body)
+	code, body = request(http.MethodGet, "/ready", e)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `{"status":"ready","version":"`+Version+`"}`, body)
+
+	// Draining
+	assert.NoError(t, e.Shutdown(stdcontext.Background()))
+	code, _ = request(http.MethodGet, "/healthz", e)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	code, _ = request(http.MethodGet, "/ready", e)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
@@ -0,0 +1,141 @@
+package route
+
+import "sync"
+
+type (
+	// SessionStore loads and saves session values. Implementations are
+	// provided by the `session` subpackage.
+	SessionStore interface {
+		// Load returns the session values of the request, or an empty map if
+		// the request has no session.
+		Load(c Context) (map[string]interface{}, error)
+
+		// Save persists values, e.g. by setting a cookie on the response.
+		Save(c Context, values map[string]interface{}) error
+	}
+
+	// SessionRegenerator is implemented by SessionStores which reference
+	// sessions by an ID, so that `Session#Regenerate()` can issue a new one.
+	SessionRegenerator interface {
+		// Regenerate persists values under a new ID and discards the session
+		// stored under the current one.
+		Regenerate(c Context, values map[string]interface{}) error
+	}
+
+	// Session holds the session values of a request. Values are loaded lazily
+	// from the store on first access.
+	Session struct {
+		mu         sync.Mutex
+		ctx        Context
+		store      SessionStore
+		values     map[string]interface{}
+		loaded     bool
+		modified   bool
+		regenerate bool
+		err        error
+	}
+)
+
+// SessionKey is the context key the Sessions middleware stores the Session
+// under, see `Context#Session()`.
+const SessionKey = "session"
+
+// Sessions returns a middleware which makes a Session backed by store available
+// via `Context#Session()`. Modified sessions are saved just before the response
+// header is written, so that stores can set cookies. Errors are not reported
+// in that case; handlers which need to handle them should call `Session#Save()`.
+// It is named Sessions as Session is the type of the session values.
+func Sessions(store SessionStore) MiddlewareFunc {
+	return func(c Context, next HandlerFunc) error {
+		s := &Session{ctx: c, store: store}
+		c.Set(SessionKey, s)
+		c.Response().Before(func() {
+			s.Save()
+		})
+		return next(c)
+	}
+}
+
+// Get returns the value stored under key.
+func (s *Session) Get(key string) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	return s.values[key]
+}
+
+// Set stores val under key.
+func (s *Session) Set(key string, val interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	s.values[key] = val
+	s.modified = true
+}
+
+// Delete removes the value stored under key.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.modified = true
+	}
+}
+
+// Regenerate issues a new ID for the session when it is saved, if the store
+// references sessions by an ID, see `SessionRegenerator`. It should be called
+// when the privileges of the session change, e.g. after login, so that an ID
+// planted by an attacker before can't be used to take over the session.
+func (s *Session) Regenerate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	s.regenerate = true
+	s.modified = true
+}
+
+// Save persists the session if it was modified. It must be called before the
+// response is written.
+func (s *Session) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.modified {
+		return nil
+	}
+	var err error
+	if r, ok := s.store.(SessionRegenerator); ok && s.regenerate {
+		err = r.Regenerate(s.ctx, s.values)
+	} else {
+		err = s.store.Save(s.ctx, s.values)
+	}
+	if err != nil {
+		return err
+	}
+	s.modified = false
+	s.regenerate = false
+	return nil
+}
+
+// Err returns the error of loading the session from the store, e.g. because
+// the session cookie was tampered with. The session starts out empty in that
+// case and replaces the stored session when saved.
+func (s *Session) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	return s.err
+}
+
+// load loads the session values from the store once.
+func (s *Session) load() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	s.values, s.err = s.store.Load(s.ctx)
+	if s.values == nil {
+		s.values = make(map[string]interface{})
+	}
+}
@@ -0,0 +1,239 @@
+// Package session provides route.SessionStore implementations for the
+// route.Sessions middleware.
+//
+//	mux.Use(route.Sessions(session.NewCookieStore("session", secret)))
+//
+// Values are encoded with encoding/gob; custom types stored in a
+// CookieStore session must be registered with gob.Register.
+package session
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/goroute/route"
+)
+
+type (
+	// CookieStore stores session values in a signed cookie. The values are
+	// readable by the client, so it must not be used for secrets.
+	CookieStore struct {
+		// Name of the cookie.
+		Name string
+
+		// Secret used to sign the cookie.
+		Secret []byte
+
+		// Options are the attributes of the cookie, e.g. Path or MaxAge. Name
+		// and Value are ignored. Default Path "/" and HttpOnly.
+		Options http.Cookie
+	}
+
+	// MemoryStore stores session values in memory, referenced by a random ID
+	// in a cookie. Sessions are lost on restart and aren't shared between
+	// processes.
+	MemoryStore struct {
+		// Name of the cookie.
+		Name string
+
+		// Options are the attributes of the cookie, e.g. Path or MaxAge. Name
+		// and Value are ignored. Default Path "/" and HttpOnly.
+		Options http.Cookie
+
+		// MaxAge is how long sessions are kept after they were last used.
+		// Expired sessions are removed when the store is next used. Default
+		// DefaultMemoryStoreMaxAge.
+		MaxAge time.Duration
+
+		mu        sync.Mutex
+		sessions  map[string]*memorySession
+		nextSweep time.Time
+		now       func() time.Time
+	}
+
+	memorySession struct {
+		values  map[string]interface{}
+		expires time.Time
+	}
+)
+
+// DefaultMemoryStoreMaxAge is how long a MemoryStore keeps unused sessions by
+// default.
+const DefaultMemoryStoreMaxAge = 24 * time.Hour
+
+// NewCookieStore returns a CookieStore which stores sessions in the cookie
+// name signed with secret.
+func NewCookieStore(name string, secret []byte) *CookieStore {
+	return &CookieStore{
+		Name:    name,
+		Secret:  secret,
+		Options: http.Cookie{Path: "/", HttpOnly: true},
+	}
+}
+
+// Load implements the `route.SessionStore#Load` function.
+func (s *CookieStore) Load(c route.Context) (map[string]interface{}, error) {
+	cookie, err := c.SignedCookie(s.Name, s.Secret)
+	if err == route.ErrCookieNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Save implements the `route.SessionStore#Save` function.
+func (s *CookieStore) Save(c route.Context, values map[string]interface{}) error {
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(values); err != nil {
+		return err
+	}
+	c.SetSignedCookie(newCookie(s.Name, base64.RawURLEncoding.EncodeToString(buf.Bytes()), s.Options), s.Secret)
+	return nil
+}
+
+// NewMemoryStore returns a MemoryStore which references sessions in the
+// cookie name.
+func NewMemoryStore(name string) *MemoryStore {
+	return &MemoryStore{
+		Name:    name,
+		Options: http.Cookie{Path: "/", HttpOnly: true},
+		MaxAge:  DefaultMemoryStoreMaxAge,
+	}
+}
+
+// Load implements the `route.SessionStore#Load` function.
+func (s *MemoryStore) Load(c route.Context) (map[string]interface{}, error) {
+	cookie, err := c.Cookie(s.Name)
+	if err != nil {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ms := s.session(cookie.Value)
+	if ms == nil {
+		return nil, nil
+	}
+	return copyValues(ms.values), nil
+}
+
+// Save implements the `route.SessionStore#Save` function. A new ID is issued
+// if the request doesn't reference a stored session.
+func (s *MemoryStore) Save(c route.Context, values map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var id string
+	if cookie, err := c.Cookie(s.Name); err == nil && s.session(cookie.Value) != nil {
+		id = cookie.Value
+	}
+	return s.save(c, id, values)
+}
+
+// Regenerate implements the `route.SessionRegenerator#Regenerate` function.
+func (s *MemoryStore) Regenerate(c route.Context, values map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cookie, err := c.Cookie(s.Name); err == nil {
+		delete(s.sessions, cookie.Value)
+	}
+	return s.save(c, "", values)
+}
+
+// save stores values under id, or a new ID if empty, and sets the cookie
+// referencing it. Expired sessions are removed once per MaxAge.
+func (s *MemoryStore) save(c route.Context, id string, values map[string]interface{}) error {
+	now := s.clock()
+	if s.sessions == nil {
+		s.sessions = make(map[string]*memorySession)
+	}
+	if now.After(s.nextSweep) {
+		for id, ms := range s.sessions {
+			if now.After(ms.expires) {
+				delete(s.sessions, id)
+			}
+		}
+		s.nextSweep = now.Add(s.maxAge())
+	}
+	if id == "" {
+		var err error
+		if id, err = newID(); err != nil {
+			return err
+		}
+	}
+	s.sessions[id] = &memorySession{values: copyValues(values), expires: now.Add(s.maxAge())}
+	c.SetCookie(newCookie(s.Name, id, s.Options))
+	return nil
+}
+
+// session returns the unexpired session stored under id, extending its
+// lifetime, or nil.
+func (s *MemoryStore) session(id string) *memorySession {
+	ms := s.sessions[id]
+	if ms == nil {
+		return nil
+	}
+	now := s.clock()
+	if now.After(ms.expires) {
+		delete(s.sessions, id)
+		return nil
+	}
+	ms.expires = now.Add(s.maxAge())
+	return ms
+}
+
+func (s *MemoryStore) maxAge() time.Duration {
+	if s.MaxAge <= 0 {
+		return DefaultMemoryStoreMaxAge
+	}
+	return s.MaxAge
+}
+
+func (s *MemoryStore) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+// newCookie returns a copy of options with name and value.
+func newCookie(name, value string, options http.Cookie) *http.Cookie {
+	cookie := options
+	cookie.Name = name
+	cookie.Value = value
+	return &cookie
+}
+
+// newID returns a random session ID.
+func newID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	m := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		m[k] = v
+	}
+	return m
+}
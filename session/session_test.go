@@ -0,0 +1,147 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goroute/route"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMux(store route.SessionStore) *route.Mux {
+	mux := route.NewServeMux()
+	mux.Use(route.Sessions(store))
+	mux.GET("/set", func(c route.Context) error {
+		c.Session().Set("count", 1)
+		c.Session().Set("user", "jon")
+		return c.NoContent(http.StatusOK)
+	})
+	mux.GET("/login", func(c route.Context) error {
+		c.Session().Set("user", "arya")
+		c.Session().Regenerate()
+		return c.NoContent(http.StatusOK)
+	})
+	mux.GET("/get", func(c route.Context) error {
+		s := c.Session()
+		if err := s.Err(); err != nil {
+			return err
+		}
+		user, _ := s.Get("user").(string)
+		return c.String(http.StatusOK, user)
+	})
+	return mux
+}
+
+func request(mux *route.Mux, path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCookieStore(t *testing.T) {
+	mux := newMux(NewCookieStore("session", []byte("secret")))
+
+	rec := request(mux, "/get")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec = request(mux, "/set")
+	cookies := rec.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, "session", cookies[0].Name)
+		assert.Equal(t, "/", cookies[0].Path)
+		assert.True(t, cookies[0].HttpOnly)
+	}
+
+	rec = request(mux, "/get", cookies...)
+	assert.Equal(t, "jon", rec.Body.String())
+
+	// Stores that cannot regenerate save the session as usual
+	rec = request(mux, "/login", cookies...)
+	rec = request(mux, "/get", rec.Result().Cookies()...)
+	assert.Equal(t, "arya", rec.Body.String())
+
+	cookies[0].Value = "x" + cookies[0].Value
+	rec = request(mux, "/get", cookies...)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore("sid")
+	mux := newMux(store)
+
+	rec := request(mux, "/set")
+	cookies := rec.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+	assert.Len(t, cookies[0].Value, 64)
+
+	rec = request(mux, "/get", cookies...)
+	assert.Equal(t, "jon", rec.Body.String())
+
+	// The session ID is kept across saves
+	rec = request(mux, "/set", cookies...)
+	assert.Equal(t, cookies[0].Value, rec.Result().Cookies()[0].Value)
+	assert.Len(t, store.sessions, 1)
+
+	rec = request(mux, "/get", &http.Cookie{Name: "sid", Value: "unknown"})
+	assert.Empty(t, rec.Body.String())
+	rec = request(mux, "/set", &http.Cookie{Name: "sid", Value: "unknown"})
+	assert.NotEqual(t, "unknown", rec.Result().Cookies()[0].Value)
+}
+
+func TestMemoryStoreRegenerate(t *testing.T) {
+	store := NewMemoryStore("sid")
+	mux := newMux(store)
+
+	cookies := request(mux, "/set").Result().Cookies()
+	rec := request(mux, "/login", cookies...)
+	regenerated := rec.Result().Cookies()
+	if !assert.Len(t, regenerated, 1) {
+		return
+	}
+	assert.NotEqual(t, cookies[0].Value, regenerated[0].Value)
+	assert.Len(t, store.sessions, 1)
+
+	rec = request(mux, "/get", regenerated...)
+	assert.Equal(t, "arya", rec.Body.String())
+	rec = request(mux, "/get", cookies...)
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestMemoryStoreMaxAge(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore("sid")
+	store.MaxAge = time.Hour
+	store.now = func() time.Time { return now }
+	mux := newMux(store)
+
+	cookies := request(mux, "/set").Result().Cookies()
+	request(mux, "/set")
+
+	// Using a session extends its lifetime
+	now = now.Add(50 * time.Minute)
+	rec := request(mux, "/get", cookies...)
+	assert.Equal(t, "jon", rec.Body.String())
+	now = now.Add(50 * time.Minute)
+	rec = request(mux, "/get", cookies...)
+	assert.Equal(t, "jon", rec.Body.String())
+
+	// Expired sessions are removed on the next save
+	assert.Len(t, store.sessions, 2)
+	request(mux, "/set")
+	assert.Len(t, store.sessions, 2)
+
+	now = now.Add(2 * time.Hour)
+	rec = request(mux, "/get", cookies...)
+	assert.Empty(t, rec.Body.String())
+	request(mux, "/set")
+	assert.Len(t, store.sessions, 1)
+}
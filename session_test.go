@@ -0,0 +1,108 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testSessionStore struct {
+	values map[string]interface{}
+	loads  int
+	err    error
+}
+
+func (s *testSessionStore) Load(c Context) (map[string]interface{}, error) {
+	s.loads++
+	return s.values, s.err
+}
+
+func (s *testSessionStore) Save(c Context, values map[string]interface{}) error {
+	s.values = values
+	c.SetCookie(&http.Cookie{Name: "session", Value: "saved"})
+	return nil
+}
+
+func TestSessions(t *testing.T) {
+	store := &testSessionStore{values: map[string]interface{}{"user": "jon"}}
+	e := NewServeMux()
+	e.Use(Sessions(store))
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, c.Session().Get("user").(string))
+	})
+	e.GET("/login", func(c Context) error {
+		c.Session().Set("user", "arya")
+		c.Session().Delete("missing")
+		return c.String(http.StatusOK, "OK")
+	})
+	e.GET("/none", func(c Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	// Sessions are loaded lazily
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/none", nil))
+	assert.Equal(t, 0, store.loads)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "jon", rec.Body.String())
+	assert.Equal(t, 1, store.loads)
+	assert.Empty(t, rec.Header().Get(HeaderSetCookie))
+
+	// Modified sessions are saved before the body is written
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login", nil))
+	assert.Equal(t, "arya", store.values["user"])
+	assert.Equal(t, "session=saved", rec.Header().Get(HeaderSetCookie))
+}
+
+func TestSessionLoadError(t *testing.T) {
+	store := &testSessionStore{err: errors.New("tampered")}
+	e := NewServeMux()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	Sessions(store)(c, func(c Context) error {
+		s := c.Session()
+		assert.EqualError(t, s.Err(), "tampered")
+		assert.Nil(t, s.Get("user"))
+		s.Set("user", "jon")
+		assert.NoError(t, s.Save())
+		return nil
+	})
+	assert.Equal(t, map[string]interface{}{"user": "jon"}, store.values)
+}
+
+func TestContextSessionWithoutMiddleware(t *testing.T) {
+	e := NewServeMux()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	assert.Nil(t, c.Session())
+}
+
+type testRegeneratingStore struct {
+	testSessionStore
+	regenerated bool
+}
+
+func (s *testRegeneratingStore) Regenerate(c Context, values map[string]interface{}) error {
+	s.regenerated = true
+	return s.Save(c, values)
+}
+
+func TestSessionRegenerate(t *testing.T) {
+	store := &testRegeneratingStore{testSessionStore: testSessionStore{values: map[string]interface{}{"user": "jon"}}}
+	e := NewServeMux()
+	e.Use(Sessions(store))
+	e.GET("/", func(c Context) error {
+		c.Session().Regenerate()
+		return c.NoContent(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.True(t, store.regenerated)
+	assert.Equal(t, "jon", store.values["user"])
+	assert.Equal(t, "session=saved", rec.Header().Get(HeaderSetCookie))
+}
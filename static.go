@@ -0,0 +1,174 @@
+package route
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// StaticConfig defines the config for serving static files.
+type StaticConfig struct {
+	// Prefix is the path prefix the files are served under.
+	Prefix string
+
+	// Root is the directory the files are served from. Defaults to the current
+	// working directory.
+	Root string
+
+	// Browse enables a generated listing page for directories without an index
+	// file. Such directories respond with 404 when disabled.
+	Browse bool
+
+	// Index is the name of the file served for directories. Defaults to
+	// "index.html".
+	Index string
+
+	// MaxAge is how long in seconds browsers may cache the files, sent as
+	// `Cache-Control: public, max-age=...`. No Cache-Control is sent if 0.
+	MaxAge int
+
+	// Immutable marks the files as never changing while cached, e.g. for
+	// assets with a fingerprint in their name, so that browsers don't
+	// revalidate them.
+	Immutable bool
+}
+
+// FileConfig defines the config for serving a single file.
+type FileConfig struct {
+	// Path is the route path the file is served under.
+	Path string
+
+	// File is the path of the file.
+	File string
+
+	// MaxAge is how long in seconds browsers may cache the file, see
+	// `StaticConfig`.
+	MaxAge int
+
+	// Immutable marks the file as never changing while cached, see
+	// `StaticConfig`.
+	Immutable bool
+}
+
+// StaticWithConfig registers a new route to serve static files with config.
+// See: `Mux#Static()`.
+func (mux *Mux) StaticWithConfig(config StaticConfig) *Route {
+	return staticWithConfig(mux, config)
+}
+
+// StaticWithConfig implements `Mux#StaticWithConfig()` for sub-routes within the
+// Group.
+func (g *Group) StaticWithConfig(config StaticConfig) {
+	staticWithConfig(g, config)
+}
+
+func staticWithConfig(i i, config StaticConfig) *Route {
+	if config.Root == "" {
+		config.Root = "." // For security we want to restrict to CWD.
+	}
+	if config.Index == "" {
+		config.Index = indexPage
+	}
+	cache := cacheControl(config.MaxAge, config.Immutable)
+	return addStatic(i, config.Prefix, func(c Context, name string) error {
+		file := filepath.Join(config.Root, name)
+		fi, err := os.Stat(file)
+		if err != nil {
+			return notFound(c)
+		}
+		if !fi.IsDir() {
+			return serveFile(c, file, cache)
+		}
+		index := filepath.Join(file, config.Index)
+		if _, err = os.Stat(index); err == nil {
+			return serveFile(c, index, cache)
+		}
+		if config.Browse {
+			return listDir(c, file)
+		}
+		return notFound(c)
+	})
+}
+
+// FileWithConfig registers a new route to serve a file with config.
+// See: `Mux#File()`.
+func (mux *Mux) FileWithConfig(config FileConfig, m ...MiddlewareFunc) *Route {
+	cache := cacheControl(config.MaxAge, config.Immutable)
+	return mux.GET(config.Path, func(c Context) error {
+		return serveFile(c, config.File, cache)
+	}, m...)
+}
+
+// FileWithConfig implements `Mux#FileWithConfig()` for sub-routes within the
+// Group.
+func (g *Group) FileWithConfig(config FileConfig) {
+	config.Path = g.prefix + config.Path
+	g.mux.FileWithConfig(config)
+}
+
+// cacheControl returns the Cache-Control header value for static files, or an
+// empty string if they shouldn't be cached.
+func cacheControl(maxAge int, immutable bool) string {
+	if maxAge <= 0 {
+		return ""
+	}
+	v := fmt.Sprintf("public, max-age=%d", maxAge)
+	if immutable {
+		v += ", immutable"
+	}
+	return v
+}
+
+// serveFile sends file with the Cache-Control header value cache, which is
+// only kept if the file is served. Conditional requests are answered with 304
+// Not Modified along with it.
+func serveFile(c Context, file, cache string) error {
+	if cache == "" {
+		return c.File(file)
+	}
+	header := c.Response().Header()
+	header.Set(HeaderCacheControl, cache)
+	err := c.File(file)
+	if err != nil && !c.Response().Committed {
+		header.Del(HeaderCacheControl)
+	}
+	return err
+}
+
+// listDir sends an HTML page linking to the entries of the dir directory.
+func listDir(c Context, dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return notFound(c)
+	}
+	defer f.Close()
+	entries, err := f.Readdir(-1)
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	base := c.Request().URL.Path
+	c.Response().Header().Set(HeaderContentType, MIMETextHTMLCharsetUTF8)
+	if _, err = fmt.Fprintf(c.Response(), "<pre>\n"); err != nil {
+		return err
+	}
+	for _, fi := range entries {
+		name := fi.Name()
+		if fi.IsDir() {
+			name += "/"
+		}
+		href := url.URL{Path: path.Join(base, name)}
+		if _, err = fmt.Fprintf(c.Response(), "<a href=\"%s\">%s</a>\n", href.String(), html.EscapeString(name)); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(c.Response(), "</pre>\n")
+	return err
+}
@@ -0,0 +1,67 @@
+package route
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// StaticFS registers a new route with path prefix to serve static files from the
+// provided file system, e.g. an `embed.FS`.
+func (mux *Mux) StaticFS(prefix string, fsys fs.FS) *Route {
+	return staticFS(mux, prefix, fsys)
+}
+
+// StaticFS implements `Mux#StaticFS()` for sub-routes within the Group.
+func (g *Group) StaticFS(prefix string, fsys fs.FS) {
+	staticFS(g, prefix, fsys)
+}
+
+func staticFS(i i, prefix string, fsys fs.FS) *Route {
+	return addStatic(i, prefix, func(c Context, name string) error {
+		return fsFile(c, fsys, strings.TrimPrefix(name, "/"))
+	})
+}
+
+// fsFile sends a response with the content of the named file from fsys.
+func fsFile(c Context, fsys fs.FS, name string) error {
+	if name == "" {
+		name = "."
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return notFound(c)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		f, err = fsys.Open(path.Join(name, indexPage))
+		if err != nil {
+			return notFound(c)
+		}
+		defer f.Close()
+		if fi, err = f.Stat(); err != nil {
+			return err
+		}
+	}
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		content = bytes.NewReader(b)
+	}
+	setETag(c.Response().Header(), fi)
+	http.ServeContent(c.Response(), c.Request(), fi.Name(), fi.ModTime(), content)
+	return nil
+}
@@ -0,0 +1,71 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxStaticFS(t *testing.T) {
+	mux := NewServeMux()
+	mux.StaticFS("/assets", os.DirFS("testdata"))
+
+	assert := assert.New(t)
+
+	// OK
+	c, b := request(http.MethodGet, "/assets/images/walle.png", mux)
+	assert.Equal(http.StatusOK, c)
+	assert.NotEmpty(b)
+
+	// No file
+	c, _ = request(http.MethodGet, "/assets/images/bolt.png", mux)
+	assert.Equal(http.StatusNotFound, c)
+
+	// Directory
+	c, _ = request(http.MethodGet, "/assets/images", mux)
+	assert.Equal(http.StatusNotFound, c)
+
+	// Directory with index.html
+	c, b = request(http.MethodGet, "/assets", mux)
+	assert.Equal(http.StatusOK, c)
+	assert.True(strings.HasPrefix(b, "<!doctype html>"))
+
+	// Sub-directory with index.html
+	c, b = request(http.MethodGet, "/assets/folder", mux)
+	assert.Equal(http.StatusOK, c)
+	assert.True(strings.HasPrefix(b, "<!doctype html>"))
+
+	// Path traversal
+	c, _ = request(http.MethodGet, "/assets/../go.mod", mux)
+	assert.Equal(http.StatusNotFound, c)
+	c, _ = request(http.MethodGet, "/assets/%2e%2e/go.mod", mux)
+	assert.Equal(http.StatusNotFound, c)
+
+	// ETag
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/assets/images/walle.png", nil))
+	etag := rec.Header().Get(HeaderETag)
+	assert.NotEmpty(etag)
+	req := httptest.NewRequest(http.MethodGet, "/assets/images/walle.png", nil)
+	req.Header.Set(HeaderIfNoneMatch, etag)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(http.StatusNotModified, rec.Code)
+}
+
+func TestGroupStaticFS(t *testing.T) {
+	mux := NewServeMux()
+	g := mux.Group("/group")
+	g.StaticFS("/assets", fstest.MapFS{
+		"hello.txt": &fstest.MapFile{Data: []byte("Hello, World!")},
+	})
+
+	c, b := request(http.MethodGet, "/group/assets/hello.txt", mux)
+	assert.Equal(t, http.StatusOK, c)
+	assert.Equal(t, "Hello, World!", b)
+}
@@ -0,0 +1,102 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxStaticWithConfig(t *testing.T) {
+	mux := NewServeMux()
+
+	assert := assert.New(t)
+
+	// Browse disabled
+	mux.StaticWithConfig(StaticConfig{Prefix: "/images", Root: "testdata/images"})
+	c, _ := request(http.MethodGet, "/images", mux)
+	assert.Equal(http.StatusNotFound, c)
+
+	// Browse enabled
+	mux.StaticWithConfig(StaticConfig{Prefix: "/files", Root: "testdata", Browse: true})
+	c, b := request(http.MethodGet, "/files/images", mux)
+	assert.Equal(http.StatusOK, c)
+	assert.Contains(b, `<a href="/files/images/walle.png">walle.png</a>`)
+
+	// Directory with index file is not listed
+	c, b = request(http.MethodGet, "/files/folder", mux)
+	assert.Equal(http.StatusOK, c)
+	assert.True(strings.HasPrefix(b, "<!doctype html>"))
+
+	// Files are served
+	c, b = request(http.MethodGet, "/files/images/walle.png", mux)
+	assert.Equal(http.StatusOK, c)
+	assert.NotEmpty(b)
+
+	// Path traversal
+	c, _ = request(http.MethodGet, "/files/../go.mod", mux)
+	assert.Equal(http.StatusNotFound, c)
+
+	// Custom index
+	mux.StaticWithConfig(StaticConfig{Prefix: "/walle", Root: "testdata/images", Index: "walle.png"})
+	c, b = request(http.MethodGet, "/walle", mux)
+	assert.Equal(http.StatusOK, c)
+	assert.NotEmpty(b)
+}
+
+func TestGroupStaticWithConfig(t *testing.T) {
+	mux := NewServeMux()
+	g := mux.Group("/group")
+	g.StaticWithConfig(StaticConfig{Prefix: "/files", Root: "testdata", Browse: true})
+
+	c, b := request(http.MethodGet, "/group/files/images", mux)
+	assert.Equal(t, http.StatusOK, c)
+	assert.Contains(t, b, `<a href="/group/files/images/walle.png">walle.png</a>`)
+}
+
+func TestStaticCacheControl(t *testing.T) {
+	mux := NewServeMux()
+	mux.StaticWithConfig(StaticConfig{Prefix: "/images", Root: "testdata/images", MaxAge: 3600})
+	mux.StaticWithConfig(StaticConfig{Prefix: "/assets", Root: "testdata/images", MaxAge: 31536000, Immutable: true})
+	mux.FileWithConfig(FileConfig{Path: "/walle", File: "testdata/images/walle.png", MaxAge: 60})
+	mux.Group("/group").FileWithConfig(FileConfig{Path: "/walle", File: "testdata/images/walle.png", MaxAge: 60, Immutable: true})
+	mux.StaticWithConfig(StaticConfig{Prefix: "/nocache", Root: "testdata/images"})
+
+	for path, want := range map[string]string{
+		"/images/walle.png":  "public, max-age=3600",
+		"/assets/walle.png":  "public, max-age=31536000, immutable",
+		"/walle":             "public, max-age=60",
+		"/group/walle":       "public, max-age=60, immutable",
+		"/nocache/walle.png": "",
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+		assert.Equal(t, want, rec.Header().Get(HeaderCacheControl), path)
+	}
+
+	// Conditional requests still work
+	req := httptest.NewRequest(http.MethodGet, "/images/walle.png", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	lastModified := rec.Header().Get(HeaderLastModified)
+	assert.NotEmpty(t, lastModified)
+
+	req = httptest.NewRequest(http.MethodGet, "/images/walle.png", nil)
+	req.Header.Set(HeaderIfModifiedSince, lastModified)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, "public, max-age=3600", rec.Header().Get(HeaderCacheControl))
+	assert.Empty(t, rec.Body.String())
+
+	// Missing files aren't cached
+	req = httptest.NewRequest(http.MethodGet, "/images/missing.png", nil)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Empty(t, rec.Header().Get(HeaderCacheControl))
+}
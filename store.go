@@ -0,0 +1,25 @@
+package route
+
+import "fmt"
+
+// GetStore returns the value stored under key in the context as T. It returns
+// false if there's no value or it isn't a T.
+//
+//	user, ok := route.GetStore[*User](c, "user")
+func GetStore[T any](c Context, key string) (T, bool) {
+	v, ok := c.Get(key).(T)
+	return v, ok
+}
+
+// MustGetStore returns the value stored under key in the context as T. It
+// panics if there's no value or it isn't a T, e.g. when it must have been set
+// by middleware.
+func MustGetStore[T any](c Context, key string) T {
+	val := c.Get(key)
+	v, ok := val.(T)
+	if !ok {
+		var zero T
+		panic(fmt.Sprintf("route: context value %q is %T, not %T", key, val, zero))
+	}
+	return v
+}
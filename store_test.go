@@ -0,0 +1,39 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStore(t *testing.T) {
+	c := NewServeMux().NewContext(nil, nil)
+	c.Set("user", &user{1, "Jon Snow"})
+	c.Set("count", 1)
+
+	u, ok := GetStore[*user](c, "user")
+	assert.True(t, ok)
+	assert.Equal(t, "Jon Snow", u.Name)
+
+	// Missing key
+	_, ok = GetStore[string](c, "missing")
+	assert.False(t, ok)
+
+	// Wrong type
+	s, ok := GetStore[string](c, "count")
+	assert.False(t, ok)
+	assert.Equal(t, "", s)
+}
+
+func TestMustGetStore(t *testing.T) {
+	c := NewServeMux().NewContext(nil, nil)
+	c.Set("count", 1)
+	assert.Equal(t, 1, MustGetStore[int](c, "count"))
+
+	assert.PanicsWithValue(t, `route: context value "count" is int, not string`, func() {
+		MustGetStore[string](c, "count")
+	})
+	assert.PanicsWithValue(t, `route: context value "missing" is <nil>, not *route.user`, func() {
+		MustGetStore[*user](c, "missing")
+	})
+}
@@ -0,0 +1,47 @@
+package route
+
+import "strings"
+
+// StripPrefix returns a middleware which removes prefix from the request path
+// before routing, e.g. for apps served by a reverse proxy under a subpath which
+// register their routes without it. Requests to paths outside of prefix are
+// not found. It must be registered with `Mux#Pre()`.
+//
+//	mux.Pre(route.StripPrefix("/app"))
+//	mux.GET("/users/:id", h) // Handles "/app/users/1"
+func StripPrefix(prefix string) MiddlewareFunc {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	return func(c Context, next HandlerFunc) error {
+		req := c.Request()
+		p, ok := trimPathPrefix(req.URL.Path, prefix)
+		if !ok {
+			return notFound(c)
+		}
+		r := req.Clone(req.Context())
+		r.URL.Path = p
+		if r.URL.RawPath != "" {
+			// The prefix may be escaped differently in RawPath, in which case
+			// Path alone is used for routing.
+			r.URL.RawPath, _ = trimPathPrefix(r.URL.RawPath, prefix)
+		}
+		c.SetRequest(r)
+		return next(c)
+	}
+}
+
+// trimPathPrefix removes the path segments of prefix from path, returning "/"
+// for path equal to prefix.
+func trimPathPrefix(path, prefix string) (string, bool) {
+	if !strings.HasPrefix(path, prefix) {
+		return "", false
+	}
+	p := path[len(prefix):]
+	if p == "" {
+		return "/", true
+	}
+	if p[0] != '/' {
+		return "", false
+	}
+	return p, true
+}
@@ -0,0 +1,45 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripPrefix(t *testing.T) {
+	e := NewServeMux()
+	e.Pre(StripPrefix("/app/"))
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "index")
+	})
+	e.GET("/users/:id", func(c Context) error {
+		return c.String(http.StatusOK, c.Path()+" "+c.Param("id")+" "+c.Request().URL.Path)
+	})
+
+	c, b := request(http.MethodGet, "/app/users/1", e)
+	assert.Equal(t, http.StatusOK, c)
+	assert.Equal(t, "/users/:id 1 /users/1", b)
+
+	c, b = request(http.MethodGet, "/app/users/a%2Fb", e)
+	assert.Equal(t, http.StatusOK, c)
+	assert.Equal(t, "/users/:id a%2Fb /users/a/b", b)
+
+	for _, path := range []string{"/app", "/app/"} {
+		c, b = request(http.MethodGet, path, e)
+		assert.Equal(t, http.StatusOK, c, path)
+		assert.Equal(t, "index", b, path)
+	}
+
+	for _, path := range []string{"/users/1", "/application", "/"} {
+		c, _ = request(http.MethodGet, path, e)
+		assert.Equal(t, http.StatusNotFound, c, path)
+	}
+
+	e.NotFoundForPrefix("/users", func(c Context) error {
+		return c.String(http.StatusNotFound, "no users")
+	})
+	c, b = request(http.MethodGet, "/users/1", e)
+	assert.Equal(t, http.StatusNotFound, c)
+	assert.Equal(t, "no users", b)
+}
@@ -0,0 +1,185 @@
+package route
+
+import (
+	"bytes"
+	stdcontext "context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TimeoutConfig defines the config for Timeout middleware.
+type TimeoutConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper Skipper
+
+	// Timeout is the maximum duration of the handler. Required.
+	Timeout time.Duration
+}
+
+// timeoutWriter buffers the response of a handler run by the Timeout
+// middleware until it completes, and discards it once the request timed out.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+// Timeout returns a middleware which cancels the request context after d and
+// responds with `ErrRequestTimeout` if the handler hasn't completed by then.
+// See: `TimeoutWithConfig()`.
+func Timeout(d time.Duration) MiddlewareFunc {
+	return TimeoutWithConfig(TimeoutConfig{Timeout: d})
+}
+
+// TimeoutWithConfig returns a Timeout middleware with config.
+//
+// The downstream handler runs in its own goroutine on a copy of the Context
+// and its request whose `StdContext()` is cancelled on timeout. Its response
+// is buffered and only written once it completes in time; writes after the
+// timeout fail with http.ErrHandlerTimeout. This has some caveats:
+//
+//   - The handler keeps running after the timeout until it returns, so it
+//     should observe `StdContext().Done()`, e.g. by passing it on to database
+//     calls.
+//   - The handler must not access the Context it was called with from other
+//     goroutines or after it returned, and middleware wrapping Timeout must
+//     not modify the Context while the handler is running.
+//   - Streaming responses are buffered, so Flush has no effect.
+//   - Panics in the handler are re-raised in the goroutine serving the request
+//     if they occur before the timeout.
+//   - The copy of the request shares the body and the multipart form files,
+//     which are closed and removed after the timeout, so the handler must not
+//     use them anymore.
+func TimeoutWithConfig(config TimeoutConfig) MiddlewareFunc {
+	if config.Timeout <= 0 {
+		panic("route: timeout middleware requires a timeout")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultSkipper
+	}
+
+	return func(c Context, next HandlerFunc) error {
+		if config.Skipper(c) {
+			return next(c)
+		}
+
+		ctx, cancel := stdcontext.WithTimeout(c.StdContext(), config.Timeout)
+		defer cancel()
+
+		cc, ok := c.(*context)
+		if !ok {
+			// Can't copy custom Context implementations, so only cancel.
+			c.SetStdContext(ctx)
+			return next(c)
+		}
+
+		tw := &timeoutWriter{header: cloneHeader(c.Response().Header())}
+		hc := cc.clone(tw)
+		hc.request = cc.request.Clone(ctx)
+
+		done := make(chan error, 1)
+		panicked := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					panicked <- r
+				}
+			}()
+			err := next(hc)
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			if tw.timedOut {
+				// Remove the files of a form parsed by the handler itself
+				if form := hc.request.MultipartForm; form != nil {
+					form.RemoveAll()
+				}
+			}
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			cc.store = hc.store
+			cc.attrs = hc.attrs
+			if form := hc.request.MultipartForm; form != nil {
+				// Removed after the request like the form of cc
+				cc.request.MultipartForm = form
+			}
+			cc.response.afterFuncs = append(cc.response.afterFuncs, hc.response.afterFuncs...)
+			header := cc.response.Header()
+			for k := range header {
+				delete(header, k)
+			}
+			for k, v := range tw.header {
+				header[k] = v
+			}
+			if hc.response.Committed {
+				cc.response.WriteHeader(tw.code)
+				cc.response.Write(tw.buf.Bytes())
+			}
+			return err
+		case r := <-panicked:
+			panic(r)
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			tw.timedOut = true
+			if ctx.Err() == stdcontext.DeadlineExceeded {
+				return ErrRequestTimeout
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// clone returns a copy of c for a handler running in another goroutine, with
+// a new Response writing to w.
+func (c *context) clone(w http.ResponseWriter) *context {
+	hc := *c
+	hc.response = NewResponse(w)
+	hc.pvalues = append([]string(nil), c.pvalues...)
+	hc.pnames = append([]string(nil), c.pnames...)
+	hc.store = make(map[string]interface{}, len(c.store))
+	for k, v := range c.store {
+		hc.store[k] = v
+	}
+	hc.attrs = make(map[AttrKey]interface{}, len(c.attrs))
+	for k, v := range c.attrs {
+		hc.attrs[k] = v
+	}
+	return &hc
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.timedOut {
+		w.code = code
+	}
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	return w.buf.Write(b)
+}
+
+func cloneHeader(h http.Header) http.Header {
+	h2 := make(http.Header, len(h))
+	for k, v := range h {
+		h2[k] = append([]string(nil), v...)
+	}
+	return h2
+}
@@ -0,0 +1,73 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout(t *testing.T) {
+	e := NewServeMux()
+	e.Use(Timeout(time.Second))
+	e.GET("/users/:id", func(c Context) error {
+		_, ok := c.StdContext().Deadline()
+		assert.True(t, ok)
+		c.Set("user", c.Param("id"))
+		c.Response().Header().Set("X-Handler", "1")
+		return c.String(http.StatusCreated, "created "+c.Param("id"))
+	})
+	e.GET("/error", func(c Context) error {
+		c.Response().Header().Set("X-Handler", "1")
+		return errors.New("failed")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "created 1", rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get("X-Handler"))
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/error", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("X-Handler"))
+}
+
+func TestTimeoutExpired(t *testing.T) {
+	e := NewServeMux()
+	e.Use(Timeout(10 * time.Millisecond))
+	writeErr := make(chan error, 1)
+	e.GET("/", func(c Context) error {
+		<-c.StdContext().Done()
+		time.Sleep(10 * time.Millisecond)
+		c.Request().Header.Set("X-Late", "1")
+		_, err := c.Response().Write([]byte("late"))
+		writeErr <- err
+		return err
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestTimeout, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "late")
+	assert.Equal(t, http.ErrHandlerTimeout, <-writeErr)
+	assert.NotContains(t, rec.Body.String(), "late")
+	// The handler got a copy of the request
+	assert.Empty(t, req.Header.Get("X-Late"))
+}
+
+func TestTimeoutPanic(t *testing.T) {
+	e := NewServeMux()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	assert.PanicsWithValue(t, "boom", func() {
+		Timeout(time.Second)(c, func(c Context) error {
+			panic("boom")
+		})
+	})
+	assert.Panics(t, func() { Timeout(0) })
+}